@@ -0,0 +1,137 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Algorithm
+// The HMAC hash function used to derive codes
+type Algorithm int
+
+const (
+	// SHA1 is HMAC-SHA1, the RFC 4226/6238 default
+	SHA1 Algorithm = iota
+	// SHA256 is HMAC-SHA256 (RFC 6238)
+	SHA256
+	// SHA512 is HMAC-SHA512 (RFC 6238)
+	SHA512
+)
+
+// algorithms lists every supported Algorithm
+var algorithms = []Algorithm{SHA1, SHA256, SHA512}
+
+// algorithmNames maps algorithms to their otpauth names
+var algorithmNames = map[Algorithm]string{
+	SHA1:   "SHA1",
+	SHA256: "SHA256",
+	SHA512: "SHA512",
+}
+
+// String
+// Return the otpauth name of the algorithm ("SHA1", "SHA256" or "SHA512")
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return "Algorithm(" + strconv.Itoa(int(a)) + ")"
+}
+
+// ParseAlgorithm
+// Look up an algorithm by its otpauth name, ignoring case
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for a, n := range algorithmNames {
+		if strings.EqualFold(n, name) {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown algorithm %q", name)
+}
+
+// newHash returns the hash constructor for the algorithm
+func (a Algorithm) newHash() (func() hash.Hash, error) {
+	switch a {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unknown algorithm %d", int(a))
+}
+
+// size returns the HMAC output length in bytes
+func (a Algorithm) size() int {
+	switch a {
+	case SHA256:
+		return sha256.Size
+	case SHA512:
+		return sha512.Size
+	}
+	return sha1.Size
+}
+
+// maxDigits is the longest code the 31-bit truncated value can fill
+const maxDigits = 9
+
+// pow10 returns 10^n for 0 <= n <= maxDigits
+func pow10(n int) uint32 {
+	p := uint32(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+// hmacCounterWith
+// Calculate the HMAC digest of the 8-byte big-endian counter using newHash
+func hmacCounterWith(newHash func() hash.Hash, secretBytes []byte, counter uint64) []byte {
+	// The counter is converted to an 8-byte big-endian
+	// unsigned integer slice
+	timeBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(timeBytes, counter)
+
+	// The counter bytes are HMAC'd with the decoded secret key bytes.
+	// The key is passed to HMAC as-is: crypto/hmac hashes keys longer than
+	// the block size and zero-pads shorter ones, so it must not be truncated.
+	mac := hmac.New(newHash, secretBytes)
+	mac.Write(timeBytes) // Concat the counter byte slice
+	return mac.Sum(nil)  // Calculate the digest
+}
+
+// TokensAllAlgorithms
+// Diagnostic: generate the digits-long code for time t with every supported
+// algorithm, to find out which one a provider actually uses
+func TokensAllAlgorithms(secretKey string, t time.Time, digits int) (map[Algorithm]string, error) {
+	if digits < 1 || digits > maxDigits {
+		return nil, fmt.Errorf("digits must be between 1 and %d, got %d", maxDigits, digits)
+	}
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	counter, err := CounterAt(t)
+	if err != nil {
+		return nil, err
+	}
+	codes := make(map[Algorithm]string, len(algorithms))
+	for _, a := range algorithms {
+		newHash, err := a.newHash()
+		if err != nil {
+			return nil, err
+		}
+		value := dynamicTruncate(hmacCounterWith(newHash, secretBytes, counter)) % pow10(digits)
+		codes[a] = fmt.Sprintf("%0*d", digits, value)
+	}
+	return codes, nil
+}
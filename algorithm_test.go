@@ -0,0 +1,60 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_TokensAllAlgorithms(t *testing.T) {
+	codes, err := TokensAllAlgorithms(rfc6238Secret, time.Unix(59, 0), 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// SHA1 is the RFC 6238 vector; the others use the 20-byte seed, so they
+	// differ from the RFC SHA256/SHA512 vectors (computed with Python's hmac)
+	want := map[Algorithm]string{
+		SHA1:   "94287082",
+		SHA256: "32247374",
+		SHA512: "69342147",
+	}
+	if len(codes) != len(want) {
+		t.Fatalf("got %d codes, want %d", len(codes), len(want))
+	}
+	for a, code := range want {
+		if codes[a] != code {
+			t.Fatalf("%v: got %q, want %q", a, codes[a], code)
+		}
+	}
+}
+
+func Test_TokensAllAlgorithms_InvalidDigits(t *testing.T) {
+	for _, digits := range []int{0, 10} {
+		if _, err := TokensAllAlgorithms(rfc6238Secret, time.Unix(59, 0), digits); err == nil {
+			t.Fatalf("digits=%d: expected error, got nil", digits)
+		}
+	}
+}
+
+func Test_Algorithm_StringParse(t *testing.T) {
+	for _, a := range algorithms {
+		back, err := ParseAlgorithm(a.String())
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", a, err)
+		}
+		if back != a {
+			t.Fatalf("%v: round-trip gave %v", a, back)
+		}
+	}
+	if got := SHA256.String(); got != "SHA256" {
+		t.Fatalf("got %q, want %q", got, "SHA256")
+	}
+	if a, err := ParseAlgorithm("sha512"); err != nil || a != SHA512 {
+		t.Fatalf("lowercase: got %v, %v", a, err)
+	}
+	if _, err := ParseAlgorithm("MD5"); err == nil {
+		t.Fatalf("expected error for MD5")
+	}
+	if got := Algorithm(7).String(); got != "Algorithm(7)" {
+		t.Fatalf("got %q, want %q", got, "Algorithm(7)")
+	}
+}
@@ -0,0 +1,85 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// steamAlphabet is the character set used by Steam Guard codes
+	steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+	// steamLength is the number of characters in a Steam Guard code
+	steamLength = 5
+	// hexAlphabet is the character set of GetTokenHexOutput
+	hexAlphabet = "0123456789abcdef"
+	// maxHexDigits is the width of the 31-bit truncated value in hex
+	maxHexDigits = 8
+)
+
+// ErrInvalidAlphabet is returned when an alphabet is too short or repeats characters
+var ErrInvalidAlphabet = errors.New("alphabet must have at least two distinct characters")
+
+// GenerateWithAlphabet
+// Generate a length-character code for time t, encoding the truncated HMAC
+// value in base len(alphabet), least significant character first
+func GenerateWithAlphabet(secretKey string, t time.Time, length int, alphabet string) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("invalid code length %d", length)
+	}
+	symbols := []rune(alphabet)
+	if len(symbols) < 2 {
+		return "", ErrInvalidAlphabet
+	}
+	seen := make(map[rune]struct{}, len(symbols))
+	for _, r := range symbols {
+		if _, dup := seen[r]; dup {
+			return "", ErrInvalidAlphabet
+		}
+		seen[r] = struct{}{}
+	}
+
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return "", err
+	}
+	counter, err := counterFor(t.Unix(), defaultPeriod)
+	if err != nil {
+		return "", err
+	}
+	value := dynamicTruncate(hmacCounter(secretBytes, counter))
+
+	var b strings.Builder
+	base := uint32(len(symbols))
+	for range length {
+		b.WriteRune(symbols[value%base])
+		value /= base
+	}
+	return b.String(), nil
+}
+
+// GetSteamToken
+// Generate a 5-character Steam Guard code for time t
+func GetSteamToken(secretKey string, t time.Time) (string, error) {
+	return GenerateWithAlphabet(secretKey, t, steamLength, steamAlphabet)
+}
+
+// GetTokenHexOutput
+// Generate the code for time t as hexDigits (1 to 8) lowercase hex digits,
+// zero-padded and most significant first. Eight digits carry the whole
+// 31-bit truncated value, so the first digit is at most 7.
+func GetTokenHexOutput(secretKey string, t time.Time, hexDigits int) (string, error) {
+	if hexDigits < 1 || hexDigits > maxHexDigits {
+		return "", fmt.Errorf("hex digits must be between 1 and %d, got %d", maxHexDigits, hexDigits)
+	}
+	code, err := GenerateWithAlphabet(secretKey, t, hexDigits, hexAlphabet)
+	if err != nil {
+		return "", err
+	}
+	// GenerateWithAlphabet writes the least significant digit first
+	b := []byte(code)
+	slices.Reverse(b)
+	return string(b), nil
+}
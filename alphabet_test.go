@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_GetSteamToken(t *testing.T) {
+	// Expected values computed with a reference Steam Guard implementation
+	cases := map[int64]string{
+		59:         "PV9M4",
+		1111111109: "PY4YB",
+	}
+	for ts, want := range cases {
+		got, err := GetSteamToken(rfc6238Secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		if got != want {
+			t.Fatalf("ts=%d: got %q, want %q", ts, got, want)
+		}
+
+		generic, err := GenerateWithAlphabet(rfc6238Secret, time.Unix(ts, 0), 5, "23456789BCDFGHJKMNPQRTVWXY")
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		if generic != got {
+			t.Fatalf("ts=%d: GenerateWithAlphabet=%q, GetSteamToken=%q", ts, generic, got)
+		}
+	}
+}
+
+func Test_GenerateWithAlphabet_InvalidAlphabet(t *testing.T) {
+	for _, alphabet := range []string{"", "A", "ABCA"} {
+		_, err := GenerateWithAlphabet(rfc6238Secret, time.Unix(59, 0), 5, alphabet)
+		if !errors.Is(err, ErrInvalidAlphabet) {
+			t.Fatalf("alphabet=%q: got %v, want ErrInvalidAlphabet", alphabet, err)
+		}
+	}
+}
+
+func Test_GenerateWithAlphabet_InvalidLength(t *testing.T) {
+	if _, err := GenerateWithAlphabet(rfc6238Secret, time.Unix(59, 0), 0, "0123456789"); err == nil {
+		t.Fatal("expected error for zero length, got nil")
+	}
+}
+
+func Test_GetTokenHexOutput(t *testing.T) {
+	// The truncated value at T=59 is 1094287082 = 0x41397eea
+	for digits, want := range map[int]string{8: "41397eea", 6: "397eea", 1: "a"} {
+		got, err := GetTokenHexOutput(rfc6238Secret, time.Unix(59, 0), digits)
+		if err != nil {
+			t.Fatalf("%d digits: unexpected error: %v", digits, err)
+		}
+		if got != want {
+			t.Fatalf("%d digits: got %q, want %q", digits, got, want)
+		}
+	}
+	for _, digits := range []int{0, 9} {
+		if _, err := GetTokenHexOutput(rfc6238Secret, time.Unix(59, 0), digits); err == nil {
+			t.Fatalf("%d digits: expected error", digits)
+		}
+	}
+}
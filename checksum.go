@@ -0,0 +1,26 @@
+package totp
+
+// doubleDigits maps a digit to the digit sum of its double (RFC 4226, Appendix C)
+var doubleDigits = [10]uint32{0, 2, 4, 6, 8, 1, 3, 5, 7, 9}
+
+// calcChecksum
+// Calculate the Luhn-style checksum digit over the low `digits` digits of
+// num, as in the RFC 4226 reference implementation
+func calcChecksum(num uint32, digits int) uint32 {
+	doubleDigit := true
+	var total uint32
+	for ; digits > 0; digits-- {
+		digit := num % 10
+		num /= 10
+		if doubleDigit {
+			digit = doubleDigits[digit]
+		}
+		total += digit
+		doubleDigit = !doubleDigit
+	}
+	result := total % 10
+	if result > 0 {
+		result = 10 - result
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_calcChecksum(t *testing.T) {
+	// Expected digits computed with the RFC 4226 reference implementation
+	cases := map[uint32]uint32{
+		755224: 3,
+		287082: 2,
+		81804:  7,
+	}
+	for num, want := range cases {
+		if got := calcChecksum(num, 6); got != want {
+			t.Fatalf("num=%d: got %d, want %d", num, got, want)
+		}
+	}
+}
+
+func Test_WithChecksum_RoundTrip(t *testing.T) {
+	o, err := New(rfc6238Secret, WithChecksum())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	code, err := o.TokenAt(time.Unix(1111111109, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "0818047" {
+		t.Fatalf("got %q, want %q", code, "0818047")
+	}
+
+	ok, err := o.ValidateAt(code, time.Unix(1111111109, 0), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected checksummed code to validate")
+	}
+
+	// The bare code and a wrong check digit are both rejected
+	for _, bad := range []string{"081804", "0818040"} {
+		if ok, _ := o.ValidateAt(bad, time.Unix(1111111109, 0), 0); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func Test_WithChecksum_NineDigits(t *testing.T) {
+	// Ten characters in all: the value no longer fits in a uint32
+	at := time.Unix(1111111109, 0)
+	o, err := New(rfc6238Secret, WithDigits(9), WithChecksum())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code, err := o.TokenAt(at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "9070818043" {
+		t.Fatalf("got %q, want %q", code, "9070818043")
+	}
+	if ok, err := o.ValidateAt(code, at, 0); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+}
@@ -0,0 +1,118 @@
+package totp
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stressGoroutines and stressIterations size the concurrency tests; run
+// them with -race
+const (
+	stressGoroutines = 16
+	stressIterations = 200
+)
+
+// stress runs fn from many goroutines at once, passing each call a
+// distinct sequence number, like Benchmark_generateTOTP_Parallel
+func stress(t *testing.T, fn func(n uint64)) {
+	t.Helper()
+	var ctr uint64
+	var wg sync.WaitGroup
+	for range stressGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range stressIterations {
+				fn(atomic.AddUint64(&ctr, 1))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_Concurrent_TokenAndValidate(t *testing.T) {
+	o, err := New(rfc6238Secret, WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stress(t, func(n uint64) {
+		// spread timestamps across goroutines deterministically
+		at := time.Unix(int64(59+n%100000), 0)
+		code, err := o.TokenAt(at)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		if ok, err := o.ValidateAt(code, at, 1); err != nil || !ok {
+			t.Errorf("ts=%d: own code rejected: (%v, %v)", at.Unix(), ok, err)
+		}
+		if _, err := GetTokenAt(rfc6238Secret, at); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func Test_Concurrent_Verifier(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	pinTime(t, at)
+	h := &OffsetHistogram{}
+	v, err := NewVerifier(rfc6238Secret, WithSkew(1), WithMetrics(h))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l, err := NewLimitedVerifier(v, newMapAttemptStore(), stressGoroutines*stressIterations, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Every goroutine races to use the same code; exactly one may win
+	var accepted atomic.Int64
+	stress(t, func(uint64) {
+		ok, err := l.AcceptAt("081804", at)
+		if ok {
+			accepted.Add(1)
+		}
+		if err != nil && err != ErrTokenReused {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	if got := accepted.Load(); got != 1 {
+		t.Fatalf("code accepted %d times, want once", got)
+	}
+	if got := h.Validations(); got != stressGoroutines*stressIterations {
+		t.Fatalf("got %d validations, want %d", got, stressGoroutines*stressIterations)
+	}
+	if got := h.Counts()[0]; got != 1 {
+		t.Fatalf("got %d matches at offset 0, want 1", got)
+	}
+}
+
+func Test_Concurrent_ProviderAndQR(t *testing.T) {
+	var current atomic.Value
+	current.Store(rfc6238Secret)
+	p, err := NewWithProvider(func() (string, error) { return current.Load().(string), nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { RegisterQREncoder(nil) })
+
+	stress(t, func(n uint64) {
+		switch n % 50 {
+		case 0:
+			current.Store(otherSecret)
+			RegisterQREncoder(nil)
+		case 25:
+			current.Store(rfc6238Secret)
+			RegisterQREncoder(func(io.Writer, string) error { return nil })
+		}
+		if _, err := p.TokenAt(time.Unix(59, 0)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := WriteQR(io.Discard, "otpauth://totp/alice?secret="+rfc6238Secret); err != nil && err != ErrQRUnavailable {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,455 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotRFCCompliant is returned by New under WithRFCStrict
+var ErrNotRFCCompliant = errors.New("configuration is not RFC 6238 compliant")
+
+// ErrSecretTooShort is returned by New when the decoded secret is shorter
+// than WithMinSecretBytes requires
+var ErrSecretTooShort = errors.New("secret is too short")
+
+// ErrAlgorithmNotAllowed is returned when the configured algorithm is
+// outside the WithAllowedAlgorithms policy
+var ErrAlgorithmNotAllowed = errors.New("algorithm not allowed by policy")
+
+// TOTP
+// A decoded secret together with its generation parameters
+type TOTP struct {
+	secret []byte
+	params
+	clock func() time.Time // nil means timeNow, see WithClock
+
+	rejectTrivial bool // see WithRejectTrivialTokens
+}
+
+// params are the settings that affect generated codes. They are comparable,
+// so two configurations are equivalent when their params are equal.
+type params struct {
+	period     int64 // step in seconds
+	multiplier int64 // windows per counter increment, see WithStepMultiplier
+	epoch      int64 // T0 in Unix seconds, see WithEpoch
+	digits     int
+	checksum   bool
+	stripZeros bool
+	algorithm  Algorithm
+
+	counterEncoding CounterEncoding
+	counterWidth    int    // bytes in a binary counter, see WithCounterWidth
+	counterPrefix   string // raw bytes, kept as a string so params stay comparable
+}
+
+// defaultParams are the RFC 6238 defaults
+var defaultParams = params{period: defaultPeriod, multiplier: 1, digits: defaultDigits, counterWidth: 8}
+
+// config holds the settings collected from options before the secret is decoded
+type config struct {
+	params
+	encoding *base32.Encoding
+	clock    func() time.Time
+	trivial  bool
+	typos    bool
+	strict   bool
+	weak     bool
+	allowed  []Algorithm // nil allows every algorithm
+	minBytes int
+}
+
+// Option
+// Configure a TOTP created with New
+type Option func(*config) error
+
+// WithBase32Encoding
+// Decode the secret with a custom base32 encoding instead of the default
+// StdEncoding without padding. An alphabet that does not match the provider
+// still decodes, but silently produces wrong codes, so only use this when
+// the provider is known to use a nonstandard alphabet.
+func WithBase32Encoding(encoding *base32.Encoding) Option {
+	return func(c *config) error {
+		if encoding == nil {
+			return errors.New("base32 encoding must not be nil")
+		}
+		c.encoding = encoding
+		return nil
+	}
+}
+
+// WithTypoCorrection
+// Read the digits 0 and 1 in a secret as the letters O and I, which base32
+// leaves out because they are easily confused. This is a convenience for
+// hand-typed secrets: if the provider meant another letter (a 1 standing
+// for L, say) the secret still decodes but produces wrong codes. It does not
+// apply to custom encodings from WithBase32Encoding.
+func WithTypoCorrection() Option {
+	return func(c *config) error {
+		c.typos = true
+		return nil
+	}
+}
+
+// WithClock
+// Read the current time from now instead of the system clock, for Token,
+// Validate, NewTicker, NewWithProvider and verifiers built on the TOTP. A
+// fixed clock makes tests deterministic; explicit-time methods such as
+// TokenAt ignore it.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) error {
+		if now == nil {
+			return errors.New("clock must not be nil")
+		}
+		c.clock = now
+		return nil
+	}
+}
+
+// WithRejectTrivialTokens
+// When validating, check codes made of one repeated digit ("000000",
+// "111111", ...) against the current window only, never against skew
+// windows, since such submissions are far more often placeholders or
+// automation than real codes. A real code is trivial in about one window
+// in 100000 at six digits; it is then still accepted in its own window
+// but not once the window has passed.
+func WithRejectTrivialTokens() Option {
+	return func(c *config) error {
+		c.trivial = true
+		return nil
+	}
+}
+
+// WithPeriod
+// Set the time step (default 30s). The period must be a whole number of
+// seconds and at least one second.
+func WithPeriod(d time.Duration) Option {
+	return func(c *config) error {
+		if d < time.Second || d%time.Second != 0 {
+			return fmt.Errorf("period must be a whole number of seconds, got %v", d)
+		}
+		c.period = int64(d / time.Second)
+		return nil
+	}
+}
+
+// WithEpoch
+// Count windows from t0 instead of the Unix epoch (RFC 6238 T0), truncated
+// to a whole second. Codes for times before t0 have no counter and fail
+// with ErrTimeBeforeEpoch, as pre-1970 times do by default.
+func WithEpoch(t0 time.Time) Option {
+	return func(c *config) error {
+		c.epoch = t0.Unix()
+		return nil
+	}
+}
+
+// WithStepMultiplier
+// Advance the counter only every n periods (default 1), for the few
+// providers that validate on a coarser grid: with a 30s period and n = 10
+// one code is valid for 300s. The period itself, as exported in URIs and
+// Config, stays unchanged; windows, remaining time and skew steps all use
+// the coarser step of period*n.
+func WithStepMultiplier(n int) Option {
+	return func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("step multiplier must be at least 1, got %d", n)
+		}
+		c.multiplier = int64(n)
+		return nil
+	}
+}
+
+// WithDigits
+// Set the code length (default 6), from 1 to 9 digits
+func WithDigits(n int) Option {
+	return func(c *config) error {
+		if n < 1 || n > maxDigits {
+			return fmt.Errorf("digits must be between 1 and %d, got %d", maxDigits, n)
+		}
+		c.digits = n
+		return nil
+	}
+}
+
+// WithAlgorithm
+// Set the HMAC hash function (default SHA1)
+func WithAlgorithm(a Algorithm) Option {
+	return func(c *config) error {
+		if _, err := a.newHash(); err != nil {
+			return err
+		}
+		c.algorithm = a
+		return nil
+	}
+}
+
+// WithAllowedAlgorithms
+// Restrict the algorithms New accepts, so a policy such as "SHA-256 or
+// better" can be enforced at enrollment. A configuration using any other
+// algorithm fails with ErrAlgorithmNotAllowed. Without this option every
+// algorithm is allowed.
+func WithAllowedAlgorithms(allowed ...Algorithm) Option {
+	return func(c *config) error {
+		if len(allowed) == 0 {
+			return errors.New("at least one algorithm must be allowed")
+		}
+		c.allowed = allowed
+		return nil
+	}
+}
+
+// WithRFCStrict
+// Reject configurations that RFC 6238 discourages. At construction New checks:
+//   - the decoded secret is at least as long as the HMAC output
+//     (20 bytes for SHA-1, 32 for SHA-256, 64 for SHA-512, RFC 6238
+//     section 5.1)
+//   - the period is at least 30 seconds (RFC 6238 section 5.2)
+//   - the code has 6 to 8 digits (RFC 4226 section 5.3, RFC 6238 section 1.2)
+//
+// Without this option any decodable secret and period are accepted.
+func WithRFCStrict() Option {
+	return func(c *config) error {
+		c.strict = true
+		return nil
+	}
+}
+
+// WithMinSecretBytes
+// Make New reject secrets that decode to fewer than n bytes (default 0, no
+// minimum), for organizations setting their own floor independent of
+// WithRFCStrict
+func WithMinSecretBytes(n int) Option {
+	return func(c *config) error {
+		if n < 0 {
+			return fmt.Errorf("minimum secret length must not be negative, got %d", n)
+		}
+		c.minBytes = n
+		return nil
+	}
+}
+
+// WithWeakSecretCheck
+// Make New reject weak secrets as described on CheckSecret
+func WithWeakSecretCheck() Option {
+	return func(c *config) error {
+		c.weak = true
+		return nil
+	}
+}
+
+// WithChecksum
+// Append the optional RFC 4226 checksum digit (Appendix C) to every code, so
+// a 6-digit configuration produces and expects 7 characters. Only use it when
+// the other side enabled the checksum too.
+func WithChecksum() Option {
+	return func(c *config) error {
+		c.checksum = true
+		return nil
+	}
+}
+
+// WithoutLeadingZeros
+// Drop the zero padding, so the window that normally yields "081804" yields
+// "81804". Only for legacy consumers that parse the code as an integer;
+// validation then expects the stripped form too.
+func WithoutLeadingZeros() Option {
+	return func(c *config) error {
+		c.stripZeros = true
+		return nil
+	}
+}
+
+// New
+// Decode the secret and apply options
+func New(secretKey string, opts ...Option) (*TOTP, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.typos {
+		secretKey = correctTypos(secretKey)
+	}
+	secretBytes, err := decodeSecretWith(secretKey, c.encoding)
+	if err != nil {
+		return nil, err
+	}
+	return newTOTP(secretBytes, c)
+}
+
+// NewHex
+// Like New, but take the secret as hexadecimal, the form of the RFC 6238
+// test seeds and of some server exports. Whitespace is ignored. Options
+// about the base32 text (WithBase32Encoding, WithTypoCorrection) do not
+// apply.
+func NewHex(secretHex string, opts ...Option) (*TOTP, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.encoding != nil || c.typos {
+		return nil, errors.New("base32 options do not apply to hex secrets")
+	}
+	secretBytes, err := hex.DecodeString(strings.Join(strings.Fields(secretHex), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex secret: %w", err)
+	}
+	return newTOTP(secretBytes, c)
+}
+
+// newTOTP checks a decoded secret against the collected settings
+func newTOTP(secretBytes []byte, c config) (*TOTP, error) {
+	o := &TOTP{secret: secretBytes, params: c.params, clock: c.clock, rejectTrivial: c.trivial}
+	if len(o.secret) < c.minBytes {
+		return nil, fmt.Errorf("%w: %d bytes, want at least %d", ErrSecretTooShort, len(o.secret), c.minBytes)
+	}
+	if c.weak {
+		if err := checkSecretBytes(o.secret); err != nil {
+			return nil, err
+		}
+	}
+	if c.strict {
+		if err := o.checkRFCStrict(); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// newConfig applies options over the defaults and checks the algorithm policy
+func newConfig(opts []Option) (config, error) {
+	c := config{params: defaultParams}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return config{}, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	if c.allowed != nil && !slices.Contains(c.allowed, c.algorithm) {
+		return config{}, fmt.Errorf("%w: %s", ErrAlgorithmNotAllowed, c.algorithm)
+	}
+	if c.typos && c.encoding != nil {
+		return config{}, errors.New("typo correction does not apply to custom base32 encodings")
+	}
+	if c.counterWidth != 8 && c.counterEncoding == CounterDecimalASCII {
+		return config{}, errors.New("counter width does not apply to decimal ASCII counters")
+	}
+	return c, nil
+}
+
+// checkRFCStrict applies the rules documented on WithRFCStrict
+func (o *TOTP) checkRFCStrict() error {
+	if size := o.algorithm.size(); len(o.secret) < size {
+		return fmt.Errorf("%w: secret is %d bytes, want at least %d", ErrNotRFCCompliant, len(o.secret), size)
+	}
+	if o.period < defaultPeriod {
+		return fmt.Errorf("%w: period is %ds, want at least %ds", ErrNotRFCCompliant, o.period, defaultPeriod)
+	}
+	if o.digits < 6 || o.digits > 8 {
+		return fmt.Errorf("%w: %d digits, want 6 to 8", ErrNotRFCCompliant, o.digits)
+	}
+	return nil
+}
+
+// SameParams
+// Report whether a and b generate identical codes: same parameters and same
+// decoded secret. The secrets are compared in constant time.
+func SameParams(a, b *TOTP) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	sameSecret := subtle.ConstantTimeCompare(a.secret, b.secret) == 1
+	return sameSecret && a.params == b.params
+}
+
+// Token
+// Generate the code for the current time
+func (o *TOTP) Token() (string, error) {
+	return o.TokenAt(o.now())
+}
+
+// now returns the current time from the configured clock
+func (o *TOTP) now() time.Time {
+	if o.clock != nil {
+		return o.clock()
+	}
+	return timeNow()
+}
+
+// TokenAt
+// Generate the code for time t
+func (o *TOTP) TokenAt(t time.Time) (string, error) {
+	counter, err := o.counterAt(t.Unix())
+	if err != nil {
+		return "", err
+	}
+	return o.code(counter), nil
+}
+
+// counterAt returns the time-step counter for a Unix timestamp
+func (o *TOTP) counterAt(timestamp int64) (uint64, error) {
+	return counterFor(timestamp-o.epoch, o.step())
+}
+
+// windowStart returns the Unix timestamp at which a counter's window begins
+func (o *TOTP) windowStart(counter uint64) int64 {
+	return o.epoch + int64(counter)*o.step()
+}
+
+// step returns the lifetime of one code in seconds
+func (o *TOTP) step() int64 {
+	return o.period * o.multiplier
+}
+
+// WindowsIn
+// Return how many whole windows fit in d, using the configured period and
+// step multiplier; a partial window at the end is not counted
+func (o *TOTP) WindowsIn(d time.Duration) int {
+	return int(d / (time.Duration(o.step()) * time.Second))
+}
+
+// code generates the code for a counter, taking the fast path when possible
+func (o *TOTP) code(counter uint64) string {
+	if o.isDefault() {
+		return fastToken(o.secret, counter)
+	}
+	return o.tokenGeneral(counter)
+}
+
+// isDefault reports whether the fast path applies: every parameter that
+// affects the code equals its default
+func (o *TOTP) isDefault() bool {
+	return o.params == defaultParams
+}
+
+// tokenLength returns the number of characters in a generated code, or 0
+// when it varies (WithoutLeadingZeros)
+func (o *TOTP) tokenLength() int {
+	if o.stripZeros {
+		return 0
+	}
+	if o.checksum {
+		return o.digits + 1
+	}
+	return o.digits
+}
+
+// tokenGeneral generates the code for any configuration
+func (o *TOTP) tokenGeneral(counter uint64) string {
+	code := dynamicTruncate(o.digest(counter)) % pow10(o.digits)
+	// Widened: nine digits plus the checksum digit overflow a uint32
+	value, width := uint64(code), o.digits
+	if o.checksum {
+		value = value*10 + uint64(calcChecksum(code, width))
+		width++
+	}
+	if o.stripZeros {
+		return strconv.FormatUint(value, 10)
+	}
+	return fmt.Sprintf("%0*d", width, value)
+}
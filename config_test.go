@@ -0,0 +1,439 @@
+package totp
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_New_DefaultMatchesRFC6238(t *testing.T) {
+	o, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code, err := o.TokenAt(time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+}
+
+func Test_WithBase32Encoding(t *testing.T) {
+	hex := base32.HexEncoding.WithPadding(base32.NoPadding)
+	secret := hex.EncodeToString([]byte("12345678901234567890"))
+	if secret == rfc6238Secret {
+		t.Fatal("expected custom alphabet to produce a different secret string")
+	}
+
+	o, err := New(secret, WithBase32Encoding(hex))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code, err := o.TokenAt(time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+}
+
+func Test_WithBase32Encoding_Nil(t *testing.T) {
+	if _, err := New(rfc6238Secret, WithBase32Encoding(nil)); err == nil {
+		t.Fatal("expected error for nil encoding, got nil")
+	}
+}
+
+func Test_WithPeriod_DefaultEquivalent(t *testing.T) {
+	def, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	explicit, err := New(rfc6238Secret, WithPeriod(30*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ts := range []int64{0, 29, 30, 59, 1111111109, 1111111111, 20000000000} {
+		a, _ := def.TokenAt(time.Unix(ts, 0))
+		b, _ := explicit.TokenAt(time.Unix(ts, 0))
+		if a != b {
+			t.Fatalf("ts=%d: default %q, WithPeriod(30s) %q", ts, a, b)
+		}
+	}
+}
+
+func Test_WithPeriod_Custom(t *testing.T) {
+	o, err := New(rfc6238Secret, WithPeriod(60*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// With a 60s step T=118 uses counter 1, which is the 30s counter at T=59
+	code, err := o.TokenAt(time.Unix(118, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+}
+
+func Test_WithPeriod_Invalid(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second, 500 * time.Millisecond, 1500 * time.Millisecond} {
+		if _, err := New(rfc6238Secret, WithPeriod(d)); err == nil {
+			t.Fatalf("period=%v: expected error, got nil", d)
+		}
+	}
+}
+
+func Test_WithRFCStrict(t *testing.T) {
+	short := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte{1, 2, 3, 4})
+
+	if _, err := New(short); err != nil {
+		t.Fatalf("default: unexpected error for 4-byte secret: %v", err)
+	}
+	if _, err := New(short, WithRFCStrict()); !errors.Is(err, ErrNotRFCCompliant) {
+		t.Fatalf("strict: got %v, want ErrNotRFCCompliant", err)
+	}
+	if _, err := New(rfc6238Secret, WithRFCStrict(), WithPeriod(15*time.Second)); !errors.Is(err, ErrNotRFCCompliant) {
+		t.Fatalf("strict 15s period: got %v, want ErrNotRFCCompliant", err)
+	}
+	if _, err := New(rfc6238Secret, WithRFCStrict()); err != nil {
+		t.Fatalf("strict: unexpected error for 20-byte secret: %v", err)
+	}
+}
+
+func Test_TokenAt_FastPathMatchesGeneral(t *testing.T) {
+	o, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !o.isDefault() {
+		t.Fatal("expected default config to select the fast path")
+	}
+	for counter := uint64(0); counter < 10000; counter++ {
+		fast := fastToken(o.secret, counter)
+		general := o.tokenGeneral(counter)
+		if fast != general {
+			t.Fatalf("counter=%d: fast %q, general %q", counter, fast, general)
+		}
+	}
+
+	custom, err := New(rfc6238Secret, WithPeriod(60*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if custom.isDefault() {
+		t.Fatal("expected custom period to select the general path")
+	}
+}
+
+func Test_SameParams(t *testing.T) {
+	a, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Same decoded secret, different spelling
+	b, err := New(strings.ToLower(rfc6238Secret), WithPeriod(30*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !SameParams(a, b) {
+		t.Fatal("expected equivalent configs to be equal")
+	}
+
+	otherPeriod, err := New(rfc6238Secret, WithPeriod(60*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if SameParams(a, otherPeriod) {
+		t.Fatal("expected different periods to differ")
+	}
+
+	different, err := New(otherSecret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if SameParams(a, different) {
+		t.Fatal("expected different secrets to differ")
+	}
+
+	if SameParams(a, nil) || !SameParams(nil, nil) {
+		t.Fatal("unexpected nil handling")
+	}
+}
+
+func Test_WithoutLeadingZeros(t *testing.T) {
+	o, err := New(rfc6238Secret, WithoutLeadingZeros())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	at := time.Unix(1111111109, 0) // normally 081804
+	code, err := o.TokenAt(at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "81804" {
+		t.Fatalf("got %q, want %q", code, "81804")
+	}
+	if ok, err := o.ValidateAt("81804", at, 0); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+
+	// Codes without a leading zero are unaffected
+	if code, _ := o.TokenAt(time.Unix(59, 0)); code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+	def, _ := New(rfc6238Secret)
+	if SameParams(o, def) {
+		t.Fatal("expected stripped config to differ from default")
+	}
+}
+
+func Test_WithDigits(t *testing.T) {
+	o, err := New(rfc6238Secret, WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code, _ := o.TokenAt(time.Unix(59, 0)); code != "94287082" {
+		t.Fatalf("got %q, want %q", code, "94287082")
+	}
+	for _, n := range []int{0, 10} {
+		if _, err := New(rfc6238Secret, WithDigits(n)); err == nil {
+			t.Fatalf("digits=%d: expected error, got nil", n)
+		}
+	}
+	if _, err := New(rfc6238Secret, WithRFCStrict(), WithDigits(5)); !errors.Is(err, ErrNotRFCCompliant) {
+		t.Fatalf("strict 5 digits: got %v, want ErrNotRFCCompliant", err)
+	}
+}
+
+func Test_WithAlgorithm(t *testing.T) {
+	// RFC 6238 Appendix B uses a seed as long as the HMAC output
+	for _, tc := range []struct {
+		algorithm Algorithm
+		seed      string
+		want      string
+	}{
+		{SHA1, "12345678901234567890", "94287082"},
+		{SHA256, "12345678901234567890123456789012", "46119246"},
+		{SHA512, "1234567890123456789012345678901234567890123456789012345678901234", "90693936"},
+	} {
+		secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(tc.seed))
+		o, err := New(secret, WithAlgorithm(tc.algorithm), WithDigits(8), WithRFCStrict())
+		if err != nil {
+			t.Fatalf("algorithm %d: unexpected error: %v", tc.algorithm, err)
+		}
+		got, _ := o.TokenAt(time.Unix(59, 0))
+		if got != tc.want {
+			t.Fatalf("algorithm %d: got %q, want %q", tc.algorithm, got, tc.want)
+		}
+	}
+
+	if _, err := New(rfc6238Secret, WithAlgorithm(SHA256), WithRFCStrict()); !errors.Is(err, ErrNotRFCCompliant) {
+		t.Fatalf("strict SHA256 with 20-byte secret: got %v, want ErrNotRFCCompliant", err)
+	}
+	if _, err := New(rfc6238Secret, WithAlgorithm(Algorithm(7))); err == nil {
+		t.Fatalf("expected error for unknown algorithm")
+	}
+}
+
+func Test_WithAllowedAlgorithms(t *testing.T) {
+	if _, err := New(rfc6238Secret, WithAllowedAlgorithms(SHA256, SHA512)); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Fatalf("SHA1 under SHA256+ policy: got %v, want ErrAlgorithmNotAllowed", err)
+	}
+	if _, err := New(rfc6238Secret, WithAlgorithm(SHA256), WithAllowedAlgorithms(SHA256, SHA512)); err != nil {
+		t.Fatalf("SHA256 under SHA256+ policy: unexpected error: %v", err)
+	}
+	if _, err := New(rfc6238Secret, WithAllowedAlgorithms()); err == nil {
+		t.Fatalf("expected error for an empty policy")
+	}
+}
+
+func Test_WithStepMultiplier(t *testing.T) {
+	o, err := New(rfc6238Secret, WithStepMultiplier(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// One code per 300s: the counter is floor(ts / 300)
+	first, _ := o.TokenAt(time.Unix(1111111200, 0))
+	for ts := int64(1111111200); ts < 1111111500; ts += 30 {
+		if got, _ := o.TokenAt(time.Unix(ts, 0)); got != first {
+			t.Fatalf("ts=%d: got %q, want %q for the whole 300s step", ts, got, first)
+		}
+	}
+	want, _ := GetHOTP(rfc6238Secret, 1111111200/300)
+	if first != want {
+		t.Fatalf("got %q, want HOTP of counter %d %q", first, 1111111200/300, want)
+	}
+	if next, _ := o.TokenAt(time.Unix(1111111500, 0)); next == first {
+		t.Fatalf("code did not change at the 300s boundary")
+	}
+
+	m, ok, err := o.matchOffsets(first, 1111111300, []int{0})
+	if err != nil || !ok || m.RemainingSeconds != 200 {
+		t.Fatalf("got (%+v, %v, %v), want 200s remaining", m, ok, err)
+	}
+	if c := o.ToConfig(); c.Period != 30 || c.StepMultiplier != 10 {
+		t.Fatalf("config exported period %d, multiplier %d", c.Period, c.StepMultiplier)
+	}
+
+	def, _ := New(rfc6238Secret, WithStepMultiplier(1))
+	if !def.isDefault() {
+		t.Fatalf("multiplier 1 should keep the default fast path")
+	}
+	if _, err := New(rfc6238Secret, WithStepMultiplier(0)); err == nil {
+		t.Fatalf("expected error for multiplier 0")
+	}
+}
+
+func Test_WithMinSecretBytes(t *testing.T) {
+	// rfc6238Secret decodes to 20 bytes
+	for n, wantErr := range map[int]bool{0: false, 19: false, 20: false, 21: true, 32: true} {
+		_, err := New(rfc6238Secret, WithMinSecretBytes(n))
+		if wantErr && !errors.Is(err, ErrSecretTooShort) {
+			t.Fatalf("min %d: got %v, want ErrSecretTooShort", n, err)
+		}
+		if !wantErr && err != nil {
+			t.Fatalf("min %d: unexpected error: %v", n, err)
+		}
+	}
+	if _, err := New(rfc6238Secret, WithMinSecretBytes(-1)); err == nil {
+		t.Fatal("expected error for a negative minimum")
+	}
+}
+
+func Test_WindowsIn(t *testing.T) {
+	cases := []struct {
+		opts []Option
+		d    time.Duration
+		want int
+	}{
+		{d: 0, want: 0},
+		{d: 29 * time.Second, want: 0},
+		{d: 30 * time.Second, want: 1},
+		{d: 89 * time.Second, want: 2},
+		{d: 24 * time.Hour, want: 2880},
+		{opts: []Option{WithPeriod(60 * time.Second)}, d: 24 * time.Hour, want: 1440},
+		{opts: []Option{WithPeriod(60 * time.Second), WithStepMultiplier(5)}, d: time.Hour, want: 12},
+	}
+	for _, tc := range cases {
+		o, err := New(rfc6238Secret, tc.opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := o.WindowsIn(tc.d); got != tc.want {
+			t.Fatalf("WindowsIn(%v) with period %ds: got %d, want %d", tc.d, o.step(), got, tc.want)
+		}
+	}
+}
+
+func Test_WithEpoch(t *testing.T) {
+	t0 := time.Unix(1111111000, 0)
+	o, err := New(rfc6238Secret, WithEpoch(t0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Windows count from T0: T0+59s is in counter 1
+	want, _ := GetTokenAtCounter(rfc6238Secret, 1)
+	if got, err := o.TokenAt(t0.Add(59 * time.Second)); err != nil || got != want {
+		t.Fatalf("got (%q, %v), want %q", got, err, want)
+	}
+	m, ok, err := o.matchOffsets(want, t0.Unix()+59, []int{0})
+	if err != nil || !ok || m.RemainingSeconds != 1 {
+		t.Fatalf("got (%+v, %v, %v), want 1s remaining", m, ok, err)
+	}
+
+	// Before T0 there is no counter, rather than a wrapped-around one
+	if _, err := o.TokenAt(t0.Add(-time.Second)); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("got %v, want ErrTimeBeforeEpoch", err)
+	}
+	pinTime(t, t0.Add(-time.Hour))
+	if _, err := o.Token(); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("Token: got %v, want ErrTimeBeforeEpoch", err)
+	}
+	if _, err := o.Validate(want, 1); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("Validate: got %v, want ErrTimeBeforeEpoch", err)
+	}
+
+	if _, err := o.URI("Example", "alice"); !errors.Is(err, ErrNotExportable) {
+		t.Fatalf("URI: got %v, want ErrNotExportable", err)
+	}
+}
+
+func Test_WithTypoCorrection(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXPIOIO" // contains the letters I and O
+	typed := "JBSWY3DPEHPK3PXP1010"
+	want, _ := GetTokenAt(secret, time.Unix(1111111109, 0))
+
+	if _, err := New(typed); err == nil {
+		t.Fatal("expected error for 0 and 1 without typo correction")
+	}
+	o, err := New(strings.ToLower(typed), WithTypoCorrection())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := o.TokenAt(time.Unix(1111111109, 0)); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, err := New(typed, WithTypoCorrection(), WithBase32Encoding(base32.HexEncoding)); err == nil {
+		t.Fatal("expected error for typo correction with a custom encoding")
+	}
+}
+
+func Test_NewHex_FixedClock(t *testing.T) {
+	// RFC 6238 Appendix B with the hex form of the seeds
+	seed := "3132333435363738393031323334353637383930"
+	for _, tc := range []struct {
+		algorithm Algorithm
+		seed      string
+		ts        int64
+		want      string
+	}{
+		{SHA1, seed, 59, "94287082"},
+		{SHA1, seed, 1111111109, "07081804"},
+		{SHA256, seed + "313233343536373839303132", 1111111109, "68084774"},
+		{SHA512, strings.Repeat(seed, 3) + "31323334", 1111111109, "25091201"},
+	} {
+		at := time.Unix(tc.ts, 0)
+		o, err := NewHex(tc.seed, WithAlgorithm(tc.algorithm), WithDigits(8), WithRFCStrict(), WithClock(func() time.Time { return at }))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.algorithm, err)
+		}
+		got, err := o.Token()
+		if err != nil || got != tc.want {
+			t.Fatalf("%s T=%d: got (%q, %v), want %q", tc.algorithm, tc.ts, got, err, tc.want)
+		}
+		if ok, err := o.Validate(tc.want, 0); err != nil || !ok {
+			t.Fatalf("%s T=%d: Validate got (%v, %v), want (true, nil)", tc.algorithm, tc.ts, ok, err)
+		}
+	}
+
+	b32, _ := New(rfc6238Secret)
+	hx, err := NewHex(strings.ToUpper(seed[:20]) + " " + seed[20:])
+	if err != nil || !SameParams(b32, hx) || string(hx.secret) != string(b32.secret) {
+		t.Fatalf("hex and base32 forms differ: %v", err)
+	}
+	if _, err := NewHex("31323g"); err == nil {
+		t.Fatal("expected error for invalid hex")
+	}
+	if _, err := NewHex(seed, WithTypoCorrection()); err == nil {
+		t.Fatal("expected error for a base32 option")
+	}
+	fixed := func() time.Time { return time.Unix(1111111109, 0) }
+	v, err := NewVerifier(rfc6238Secret, WithTOTPOptions(WithClock(fixed)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, err := v.Accept("081804"); err != nil || !ok {
+		t.Fatalf("verifier with a fixed clock: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if _, err := New(rfc6238Secret, WithClock(nil)); err == nil {
+		t.Fatal("expected error for a nil clock")
+	}
+}
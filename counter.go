@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// CounterEncoding
+// How the counter is serialized before HMAC. RFC 4226 mandates an 8-byte
+// big-endian integer; the others exist only to interoperate with (and
+// diagnose) nonconforming vendors.
+type CounterEncoding int
+
+const (
+	// CounterBigEndian is the RFC 4226 8-byte big-endian counter (default)
+	CounterBigEndian CounterEncoding = iota
+	// CounterLittleEndian is an 8-byte little-endian counter (nonstandard)
+	CounterLittleEndian
+	// CounterDecimalASCII is the counter as decimal ASCII digits (nonstandard)
+	CounterDecimalASCII
+)
+
+// WithCounterEncoding
+// Serialize the counter with a nonstandard encoding. Anything other than
+// CounterBigEndian breaks compatibility with every RFC-conforming
+// authenticator; only use it to match a vendor known to do the same.
+func WithCounterEncoding(e CounterEncoding) Option {
+	return func(c *config) error {
+		switch e {
+		case CounterBigEndian, CounterLittleEndian, CounterDecimalASCII:
+		default:
+			return fmt.Errorf("unknown counter encoding %d", int(e))
+		}
+		c.counterEncoding = e
+		return nil
+	}
+}
+
+// WithCounterWidth
+// Serialize the counter in width bytes, 4 or 8 (default 8). A 4-byte
+// counter is a bug of some legacy implementations and is nonstandard: only
+// use it to keep tokens issued against one working while migrating away.
+// The counter is truncated to its low 32 bits, which holds until 2106 with
+// a 30s period. It applies to the binary encodings only.
+func WithCounterWidth(width int) Option {
+	return func(c *config) error {
+		if width != 4 && width != 8 {
+			return fmt.Errorf("counter width must be 4 or 8, got %d", width)
+		}
+		c.counterWidth = width
+		return nil
+	}
+}
+
+// WithCounterPrefix
+// Prepend fixed bytes (a salt) to the serialized counter before HMAC, as a
+// proprietary variant does. This is nonstandard: any nonempty prefix breaks
+// compatibility with RFC-conforming authenticators. An empty prefix is the
+// RFC behavior.
+func WithCounterPrefix(prefix []byte) Option {
+	return func(c *config) error {
+		c.counterPrefix = string(prefix)
+		return nil
+	}
+}
+
+// message builds the HMAC input for a counter
+func (o *TOTP) message(counter uint64) []byte {
+	msg := []byte(o.counterPrefix)
+	order := binary.AppendByteOrder(binary.BigEndian)
+	switch o.counterEncoding {
+	case CounterLittleEndian:
+		order = binary.LittleEndian
+	case CounterDecimalASCII:
+		return strconv.AppendUint(msg, counter, 10)
+	}
+	if o.counterWidth == 4 {
+		return order.AppendUint32(msg, uint32(counter))
+	}
+	return order.AppendUint64(msg, counter)
+}
+
+// digest calculates the HMAC of the configured message for a counter
+func (o *TOTP) digest(counter uint64) []byte {
+	// The algorithm was checked by WithAlgorithm
+	newHash, _ := o.algorithm.newHash()
+	mac := hmac.New(newHash, o.secret)
+	mac.Write(o.message(counter))
+	return mac.Sum(nil)
+}
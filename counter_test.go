@@ -0,0 +1,136 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_WithCounterEncoding(t *testing.T) {
+	big, err := New(rfc6238Secret, WithCounterEncoding(CounterBigEndian))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	little, err := New(rfc6238Secret, WithCounterEncoding(CounterLittleEndian))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ascii, err := New(rfc6238Secret, WithCounterEncoding(CounterDecimalASCII))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for ts, want := range vectors {
+		at := time.Unix(ts, 0)
+		if got, _ := big.TokenAt(at); got != want {
+			t.Fatalf("big-endian ts=%d: got %q, want %q", ts, got, want)
+		}
+		// Force the generalized path so the encoding switch itself is covered
+		counter, _ := big.counterAt(ts)
+		if got := big.tokenGeneral(counter); got != want {
+			t.Fatalf("big-endian general ts=%d: got %q, want %q", ts, got, want)
+		}
+		l, _ := little.TokenAt(at)
+		a, _ := ascii.TokenAt(at)
+		if l == want || a == want || l == a {
+			t.Fatalf("ts=%d: expected alternates to differ: big %q, little %q, ascii %q", ts, want, l, a)
+		}
+		// Deterministic across calls
+		if again, _ := little.TokenAt(at); again != l {
+			t.Fatalf("ts=%d: little-endian not deterministic", ts)
+		}
+	}
+}
+
+func Test_WithCounterEncoding_Unknown(t *testing.T) {
+	if _, err := New(rfc6238Secret, WithCounterEncoding(CounterEncoding(42))); err == nil {
+		t.Fatal("expected error for unknown encoding, got nil")
+	}
+}
+
+func Test_WithCounterPrefix(t *testing.T) {
+	empty, err := New(rfc6238Secret, WithCounterPrefix(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	salted, err := New(rfc6238Secret, WithCounterPrefix([]byte("salt")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def, _ := New(rfc6238Secret)
+	if !SameParams(empty, def) {
+		t.Fatalf("empty prefix should equal the default configuration")
+	}
+
+	for ts, want := range map[int64]string{59: "287082", 1111111109: "081804", 2000000000: "279037"} {
+		at := time.Unix(ts, 0)
+		if got, _ := empty.TokenAt(at); got != want {
+			t.Fatalf("empty prefix ts=%d: got %q, want %q", ts, got, want)
+		}
+		s, _ := salted.TokenAt(at)
+		if s == want {
+			t.Fatalf("ts=%d: salted code equals the RFC code %q", ts, want)
+		}
+		if again, _ := salted.TokenAt(at); again != s {
+			t.Fatalf("ts=%d: salted code not deterministic", ts)
+		}
+	}
+
+	// Only the option's bytes at call time matter
+	prefix := []byte("salt")
+	o, _ := New(rfc6238Secret, WithCounterPrefix(prefix))
+	prefix[0] = 'X'
+	if !SameParams(o, salted) {
+		t.Fatalf("prefix was aliased")
+	}
+}
+
+func Test_WithCounterWidth(t *testing.T) {
+	wide, err := New(rfc6238Secret, WithCounterWidth(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	narrow, err := New(rfc6238Secret, WithCounterWidth(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !wide.isDefault() {
+		t.Fatal("width 8 should keep the default fast path")
+	}
+	if got := len(narrow.message(1)); got != 4 {
+		t.Fatalf("4-byte message has %d bytes", got)
+	}
+
+	for ts, want := range map[int64]string{59: "287082", 1111111109: "081804", 2000000000: "279037"} {
+		at := time.Unix(ts, 0)
+		if got, _ := wide.TokenAt(at); got != want {
+			t.Fatalf("width 8 ts=%d: got %q, want %q", ts, got, want)
+		}
+		n, _ := narrow.TokenAt(at)
+		if n == want {
+			t.Fatalf("width 4 ts=%d: code equals the RFC code %q", ts, want)
+		}
+		if again, _ := narrow.TokenAt(at); again != n {
+			t.Fatalf("width 4 ts=%d: not deterministic", ts)
+		}
+	}
+
+	// HMAC over the 4-byte big-endian counter 1, computed with Python's hmac
+	if got, _ := narrow.TokenAt(time.Unix(59, 0)); got != "675152" {
+		t.Fatalf("width 4 ts=59: got %q, want %q", got, "675152")
+	}
+
+	for _, width := range []int{0, 2, 16} {
+		if _, err := New(rfc6238Secret, WithCounterWidth(width)); err == nil {
+			t.Fatalf("width %d: expected error", width)
+		}
+	}
+	if _, err := New(rfc6238Secret, WithCounterWidth(4), WithCounterEncoding(CounterDecimalASCII)); err == nil {
+		t.Fatal("expected error for a width with decimal ASCII counters")
+	}
+}
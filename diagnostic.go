@@ -0,0 +1,160 @@
+package totp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"time"
+)
+
+// rfcVectorTimes are the test times of RFC 6238 Appendix B
+var rfcVectorTimes = []int64{59, 1111111109, 1111111111, 1234567890, 2000000000, 20000000000}
+
+// TimedCode
+// A code together with the time it was generated for
+type TimedCode struct {
+	Time time.Time
+	Code string
+}
+
+// maxWindowSearch bounds FindWindowByCode (about 35 days of 30s windows)
+const maxWindowSearch = 100_000
+
+// DiagnoseToken
+// Diagnostic: generate the 6-digit code for time t together with the
+// dynamic truncation offset (0–15) used to derive it, for audit logs that
+// reconstruct the derivation. Neither the secret nor the digest is exposed.
+func DiagnoseToken(secretKey string, t time.Time) (code string, offset int, err error) {
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return "", 0, err
+	}
+	counter, err := counterFor(t.Unix(), defaultPeriod)
+	if err != nil {
+		return "", 0, err
+	}
+	h := hmacCounter(secretBytes, counter)
+	return fmt.Sprintf("%06d", dynamicTruncate(h)%digitsModulo), truncationOffset(h), nil
+}
+
+// WindowNonce
+// Return a 32-byte opaque value bound to the secret and the window
+// containing t, e.g. for CSRF-style binding. It is derived from the same
+// HMAC as the code but hashed, so handing it out reveals neither the code
+// nor the digest it comes from. Equal for every t in the same window.
+func WindowNonce(secretKey string, t time.Time) ([]byte, error) {
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	counter, err := counterFor(t.Unix(), defaultPeriod)
+	if err != nil {
+		return nil, err
+	}
+	return windowNonce(secretBytes, counter), nil
+}
+
+// windowNonce returns the WindowNonce of a counter
+func windowNonce(secretBytes []byte, counter uint64) []byte {
+	sum := sha256.Sum256(hmacCounter(secretBytes, counter))
+	return sum[:]
+}
+
+// VerifyNonce
+// Check a WindowNonce presented by a client against the window containing
+// t and skew windows on each side. Every window is compared in constant
+// time and all of them are always checked, so timing reveals neither the
+// nonce nor which window matched.
+func VerifyNonce(secretKey string, nonce []byte, t time.Time, skew int) (bool, error) {
+	if err := checkSkew(skew); err != nil {
+		return false, err
+	}
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return false, err
+	}
+	now, err := counterFor(t.Unix(), defaultPeriod)
+	if err != nil {
+		return false, err
+	}
+	match := 0
+	for _, offset := range skewOffsets(skew) {
+		counter := int64(now) + int64(offset)
+		if counter < 0 {
+			continue
+		}
+		match |= subtle.ConstantTimeCompare(windowNonce(secretBytes, uint64(counter)), nonce)
+	}
+	return match == 1, nil
+}
+
+// FindWindowByCode
+// Forensic search: scan the windows of the last searchBack, newest first,
+// for one that produced code and return its start. Six-digit codes repeat
+// by chance about once per million windows, so the longer the search the
+// likelier an older coincidental match; the newest match is returned. The
+// search is capped at 100000 windows.
+func FindWindowByCode(secretKey, code string, searchBack time.Duration) (time.Time, bool, error) {
+	if searchBack < 0 {
+		return time.Time{}, false, fmt.Errorf("search range must not be negative, got %v", searchBack)
+	}
+	if searchBack > maxWindowSearch*defaultPeriod*time.Second {
+		return time.Time{}, false, fmt.Errorf("%w: %v, at most %d windows", ErrRangeTooLarge, searchBack, maxWindowSearch)
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	ts := timeNow().Unix()
+	offsets, err := o.lookbackOffsets(ts, searchBack)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	m, ok, err := o.matchOffsets(code, ts, offsets)
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+	return time.Unix(o.windowStart(m.Counter), 0).UTC(), true, nil
+}
+
+// IsSuccessor
+// Report whether second is the code of the window right after the one of
+// first, to detect a rollover between two reads. The window of second is
+// located with FindWindowByCode over the last searchBack (with the same
+// cap), and first must be the code of the window before it.
+func IsSuccessor(secretKey, first, second string, searchBack time.Duration) (bool, error) {
+	windowStart, ok, err := FindWindowByCode(secretKey, second, searchBack)
+	if err != nil || !ok {
+		return false, err
+	}
+	first, err = cleanToken(first)
+	if err != nil {
+		return false, err
+	}
+	prev, err := GetTokenAt(secretKey, windowStart.Add(-defaultPeriod*time.Second))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(prev), []byte(first)) == 1, nil
+}
+
+// RFCVectorTokens
+// Generate the 8-digit HMAC-SHA1 codes of the secret at the RFC 6238
+// Appendix B test times (59, 1111111109, ...), to compare by eye with the
+// table in the RFC. With the RFC seed they match the SHA1 column.
+func RFCVectorTokens(secretKey string) ([]TimedCode, error) {
+	o, err := New(secretKey, WithDigits(8))
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]TimedCode, 0, len(rfcVectorTimes))
+	for _, ts := range rfcVectorTimes {
+		t := time.Unix(ts, 0).UTC()
+		code, err := o.TokenAt(t)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, TimedCode{Time: t, Code: code})
+	}
+	return codes, nil
+}
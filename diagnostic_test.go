@@ -0,0 +1,159 @@
+package totp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_DiagnoseToken_RFC6238(t *testing.T) {
+	cases := []struct {
+		ts         int64
+		wantCode   string
+		wantOffset int
+	}{
+		{ts: 59, wantCode: "287082", wantOffset: 11},
+		{ts: 1111111109, wantCode: "081804", wantOffset: 4},
+		{ts: 2000000000, wantCode: "279037", wantOffset: 15},
+	}
+	for _, tc := range cases {
+		code, offset, err := DiagnoseToken(rfc6238Secret, time.Unix(tc.ts, 0))
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", tc.ts, err)
+		}
+		if code != tc.wantCode || offset != tc.wantOffset {
+			t.Fatalf("ts=%d: got (%q, %d), want (%q, %d)", tc.ts, code, offset, tc.wantCode, tc.wantOffset)
+		}
+	}
+}
+
+func Test_WindowNonce(t *testing.T) {
+	a, err := WindowNonce(rfc6238Secret, time.Unix(1111111080, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := WindowNonce(rfc6238Secret, time.Unix(1111111109, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(a) != 32 || !bytes.Equal(a, b) {
+		t.Fatalf("same window gave different nonces: %x vs %x", a, b)
+	}
+	next, _ := WindowNonce(rfc6238Secret, time.Unix(1111111110, 0))
+	if bytes.Equal(a, next) {
+		t.Fatalf("adjacent windows gave the same nonce %x", a)
+	}
+	other, _ := WindowNonce("JBSWY3DPEHPK3PXP", time.Unix(1111111109, 0))
+	if bytes.Equal(a, other) {
+		t.Fatalf("different secrets gave the same nonce %x", a)
+	}
+}
+
+func Test_FindWindowByCode(t *testing.T) {
+	pinTime(t, time.Unix(1111111200, 0))
+
+	// 081804 was valid in the window starting at 1111111080
+	start, ok, err := FindWindowByCode(rfc6238Secret, "081804", 5*time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("got (%v, %v), want a match", ok, err)
+	}
+	if start.Unix() != 1111111080 {
+		t.Fatalf("got window start %d, want %d", start.Unix(), 1111111080)
+	}
+
+	// Too short a search misses it
+	if _, ok, err := FindWindowByCode(rfc6238Secret, "081804", time.Minute); err != nil || ok {
+		t.Fatalf("short search: got (%v, %v), want (false, nil)", ok, err)
+	}
+	if _, _, err := FindWindowByCode(rfc6238Secret, "081804", 365*24*time.Hour); !errors.Is(err, ErrRangeTooLarge) {
+		t.Fatalf("got %v, want ErrRangeTooLarge", err)
+	}
+}
+
+func Test_IsSuccessor(t *testing.T) {
+	pinTime(t, time.Unix(1111111200, 0))
+
+	// Windows starting at 1111111080, 1111111110 and 1111111140
+	for _, tc := range []struct {
+		first, second string
+		want          bool
+	}{
+		{"081804", "050471", true},
+		{"050471", "266759", true},
+		{"081804", "266759", false}, // two windows apart
+		{"050471", "081804", false}, // reversed
+		{"081804", "081804", false}, // same window
+		{"081804", "000000", false}, // second matches no window
+	} {
+		got, err := IsSuccessor(rfc6238Secret, tc.first, tc.second, 5*time.Minute)
+		if err != nil || got != tc.want {
+			t.Fatalf("(%s, %s): got (%v, %v), want %v", tc.first, tc.second, got, err, tc.want)
+		}
+	}
+	if _, err := IsSuccessor(rfc6238Secret, "081804", "050471", 365*24*time.Hour); !errors.Is(err, ErrRangeTooLarge) {
+		t.Fatalf("got %v, want ErrRangeTooLarge", err)
+	}
+}
+
+func Test_VerifyNonce(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	nonce, err := WindowNonce(rfc6238Secret, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		at   time.Time
+		skew int
+		want bool
+	}{
+		{at, 0, true},
+		{at.Add(30 * time.Second), 1, true},  // one window later, within skew
+		{at.Add(-30 * time.Second), 1, true}, // one window earlier
+		{at.Add(30 * time.Second), 0, false},
+		{at.Add(90 * time.Second), 2, false},
+	} {
+		ok, err := VerifyNonce(rfc6238Secret, nonce, tc.at, tc.skew)
+		if err != nil {
+			t.Fatalf("t=%d skew=%d: unexpected error: %v", tc.at.Unix(), tc.skew, err)
+		}
+		if ok != tc.want {
+			t.Fatalf("t=%d skew=%d: got %v, want %v", tc.at.Unix(), tc.skew, ok, tc.want)
+		}
+	}
+
+	if ok, _ := VerifyNonce(rfc6238Secret, nonce[:16], at, 1); ok {
+		t.Fatal("truncated nonce accepted")
+	}
+	if _, err := VerifyNonce(rfc6238Secret, nonce, at, -1); !errors.Is(err, ErrNegativeSkew) {
+		t.Fatalf("got %v, want ErrNegativeSkew", err)
+	}
+}
+
+func Test_RFCVectorTokens(t *testing.T) {
+	codes, err := RFCVectorTokens(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// RFC 6238 Appendix B, SHA1 column
+	want := []struct {
+		ts   int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	if len(codes) != len(want) {
+		t.Fatalf("got %d codes, want %d", len(codes), len(want))
+	}
+	for i, w := range want {
+		if codes[i].Time.Unix() != w.ts || codes[i].Code != w.code {
+			t.Fatalf("row %d: got (%d, %q), want (%d, %q)", i, codes[i].Time.Unix(), codes[i].Code, w.ts, w.code)
+		}
+	}
+}
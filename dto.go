@@ -0,0 +1,216 @@
+package totp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Config
+// A flat, serializable form of a TOTP for gRPC/proto or JSON boundaries,
+// decoupled from the internal representation. Zero Period and Digits mean
+// the defaults.
+type Config struct {
+	Secret            string `json:"secret"` // base32, no padding
+	Period            int    `json:"period"` // seconds
+	Digits            int    `json:"digits"`
+	StepMultiplier    int    `json:"step_multiplier,omitempty"` // zero means 1
+	Checksum          bool   `json:"checksum,omitempty"`
+	StripLeadingZeros bool   `json:"strip_leading_zeros,omitempty"`
+	CounterEncoding   string `json:"counter_encoding,omitempty"` // "", "big-endian", "little-endian" or "decimal-ascii"
+	Algorithm         string `json:"algorithm,omitempty"`        // "", "SHA1", "SHA256" or "SHA512"
+	CounterPrefix     []byte `json:"counter_prefix,omitempty"`   // base64 in JSON
+	CounterWidth      int    `json:"counter_width,omitempty"`    // zero means 8
+	Epoch             int64  `json:"epoch,omitempty"`            // T0 in Unix seconds
+}
+
+// counterEncodingNames maps encodings to their Config names
+var counterEncodingNames = map[CounterEncoding]string{
+	CounterBigEndian:    "big-endian",
+	CounterLittleEndian: "little-endian",
+	CounterDecimalASCII: "decimal-ascii",
+}
+
+// ToConfig
+// Export the parameters and secret as a Config
+func (o *TOTP) ToConfig() Config {
+	return Config{
+		Secret:            EncodeSecret(o.secret),
+		Period:            int(o.period),
+		Digits:            o.digits,
+		StepMultiplier:    int(o.multiplier),
+		Checksum:          o.checksum,
+		StripLeadingZeros: o.stripZeros,
+		CounterEncoding:   counterEncodingNames[o.counterEncoding],
+		Algorithm:         o.algorithm.String(),
+		CounterPrefix:     []byte(o.counterPrefix),
+		CounterWidth:      o.counterWidth,
+		Epoch:             o.epoch,
+	}
+}
+
+// FromConfig
+// Create a TOTP from a Config, validating it like New
+func FromConfig(c Config) (*TOTP, error) {
+	var opts []Option
+	if c.Period != 0 {
+		opts = append(opts, WithPeriod(time.Duration(c.Period)*time.Second))
+	}
+	if c.Digits != 0 {
+		opts = append(opts, WithDigits(c.Digits))
+	}
+	if c.StepMultiplier != 0 {
+		opts = append(opts, WithStepMultiplier(c.StepMultiplier))
+	}
+	if c.Checksum {
+		opts = append(opts, WithChecksum())
+	}
+	if c.StripLeadingZeros {
+		opts = append(opts, WithoutLeadingZeros())
+	}
+	if c.CounterEncoding != "" {
+		e, ok := parseCounterEncoding(c.CounterEncoding)
+		if !ok {
+			return nil, fmt.Errorf("unknown counter encoding %q", c.CounterEncoding)
+		}
+		opts = append(opts, WithCounterEncoding(e))
+	}
+	if c.CounterWidth != 0 {
+		opts = append(opts, WithCounterWidth(c.CounterWidth))
+	}
+	if len(c.CounterPrefix) > 0 {
+		opts = append(opts, WithCounterPrefix(c.CounterPrefix))
+	}
+	if c.Epoch != 0 {
+		opts = append(opts, WithEpoch(time.Unix(c.Epoch, 0)))
+	}
+	if c.Algorithm != "" {
+		a, err := ParseAlgorithm(c.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAlgorithm(a))
+	}
+	return New(c.Secret, opts...)
+}
+
+// parseCounterEncoding looks up an encoding by its Config name
+func parseCounterEncoding(name string) (CounterEncoding, bool) {
+	for e, n := range counterEncodingNames {
+		if n == name {
+			return e, true
+		}
+	}
+	return 0, false
+}
+
+// binaryVersion is the first byte of the MarshalBinary format
+const binaryVersion = 1
+
+// ErrMalformedBinary is returned by UnmarshalBinary for truncated or
+// inconsistent input
+var ErrMalformedBinary = errors.New("malformed binary TOTP")
+
+// MarshalBinary
+// Encode the parameters and secret compactly, for caching configured
+// generators; implements encoding.BinaryMarshaler. As with Config, the
+// clock and validation settings are not included.
+func (o *TOTP) MarshalBinary() ([]byte, error) {
+	var flags byte
+	if o.checksum {
+		flags |= 1
+	}
+	if o.stripZeros {
+		flags |= 2
+	}
+	b := []byte{binaryVersion, byte(o.algorithm), byte(o.digits), flags, byte(o.counterEncoding), byte(o.counterWidth)}
+	b = binary.AppendUvarint(b, uint64(o.period))
+	b = binary.AppendUvarint(b, uint64(o.multiplier))
+	b = binary.AppendVarint(b, o.epoch)
+	b = binary.AppendUvarint(b, uint64(len(o.counterPrefix)))
+	b = append(b, o.counterPrefix...)
+	b = binary.AppendUvarint(b, uint64(len(o.secret)))
+	return append(b, o.secret...), nil
+}
+
+// UnmarshalBinary
+// Decode the output of MarshalBinary into o, validating it like New;
+// implements encoding.BinaryUnmarshaler
+func (o *TOTP) UnmarshalBinary(data []byte) error {
+	if len(data) < 6 {
+		return ErrMalformedBinary
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("%w: unknown version %d", ErrMalformedBinary, data[0])
+	}
+	algorithm, digits, flags, encoding, width := Algorithm(data[1]), int(data[2]), data[3], CounterEncoding(data[4]), int(data[5])
+	if flags&^3 != 0 {
+		return fmt.Errorf("%w: unknown flags %#x", ErrMalformedBinary, flags)
+	}
+	r := data[6:]
+	period, ok := readUvarint(&r)
+	multiplier, ok2 := readUvarint(&r)
+	epoch, n := binary.Varint(r)
+	if !ok || !ok2 || n <= 0 {
+		return ErrMalformedBinary
+	}
+	r = r[n:]
+	prefix, ok := readBytes(&r)
+	secret, ok2 := readBytes(&r)
+	if !ok || !ok2 || len(r) != 0 {
+		return ErrMalformedBinary
+	}
+	if period > math.MaxInt64/uint64(time.Second) || multiplier > math.MaxInt32 {
+		return fmt.Errorf("%w: period or step multiplier out of range", ErrMalformedBinary)
+	}
+
+	opts := []Option{
+		WithAlgorithm(algorithm),
+		WithDigits(digits),
+		WithPeriod(time.Duration(period) * time.Second),
+		WithStepMultiplier(int(multiplier)),
+		WithEpoch(time.Unix(epoch, 0)),
+		WithCounterEncoding(encoding),
+		WithCounterWidth(width),
+		WithCounterPrefix(prefix),
+	}
+	if flags&1 != 0 {
+		opts = append(opts, WithChecksum())
+	}
+	if flags&2 != 0 {
+		opts = append(opts, WithoutLeadingZeros())
+	}
+	c, err := newConfig(opts)
+	if err != nil {
+		return err
+	}
+	decoded, err := newTOTP(secret, c)
+	if err != nil {
+		return err
+	}
+	*o = *decoded
+	return nil
+}
+
+// readUvarint consumes a uvarint from the front of *r
+func readUvarint(r *[]byte) (uint64, bool) {
+	v, n := binary.Uvarint(*r)
+	if n <= 0 {
+		return 0, false
+	}
+	*r = (*r)[n:]
+	return v, true
+}
+
+// readBytes consumes a length-prefixed byte string from the front of *r
+func readBytes(r *[]byte) ([]byte, bool) {
+	n, ok := readUvarint(r)
+	if !ok || n > uint64(len(*r)) {
+		return nil, false
+	}
+	b := append([]byte(nil), (*r)[:n]...)
+	*r = (*r)[n:]
+	return b, true
+}
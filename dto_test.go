@@ -0,0 +1,120 @@
+package totp
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Config_RoundTrip(t *testing.T) {
+	configs := [][]Option{
+		nil,
+		{WithPeriod(60 * time.Second), WithDigits(8)},
+		{WithChecksum(), WithCounterEncoding(CounterLittleEndian)},
+		{WithoutLeadingZeros(), WithCounterEncoding(CounterDecimalASCII)},
+		{WithAlgorithm(SHA512), WithCounterPrefix([]byte("salt"))},
+		{WithStepMultiplier(10), WithCounterWidth(4)},
+		{WithEpoch(time.Unix(1_000_000_000, 0))},
+	}
+	for i, opts := range configs {
+		o, err := New(rfc6238Secret, opts...)
+		if err != nil {
+			t.Fatalf("config %d: unexpected error: %v", i, err)
+		}
+		c := o.ToConfig()
+		if c.Secret != rfc6238Secret {
+			t.Fatalf("config %d: secret %q, want %q", i, c.Secret, rfc6238Secret)
+		}
+		back, err := FromConfig(c)
+		if err != nil {
+			t.Fatalf("config %d: unexpected error: %v", i, err)
+		}
+		if !SameParams(o, back) {
+			t.Fatalf("config %d: round-trip changed parameters: %+v", i, c)
+		}
+		a, _ := o.TokenAt(time.Unix(1111111109, 0))
+		b, _ := back.TokenAt(time.Unix(1111111109, 0))
+		if a != b {
+			t.Fatalf("config %d: codes differ: %q vs %q", i, a, b)
+		}
+	}
+}
+
+func Test_FromConfig_Defaults(t *testing.T) {
+	o, err := FromConfig(Config{Secret: rfc6238Secret})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def, _ := New(rfc6238Secret)
+	if !SameParams(o, def) {
+		t.Fatal("expected zero fields to mean defaults")
+	}
+	if _, err := FromConfig(Config{Secret: rfc6238Secret, CounterEncoding: "middle-endian"}); err == nil {
+		t.Fatal("expected error for unknown counter encoding, got nil")
+	}
+}
+
+func Test_MarshalBinary_RoundTrip(t *testing.T) {
+	var _ encoding.BinaryMarshaler = (*TOTP)(nil)
+	var _ encoding.BinaryUnmarshaler = (*TOTP)(nil)
+
+	configs := [][]Option{
+		nil,
+		{WithPeriod(60 * time.Second), WithDigits(8), WithAlgorithm(SHA256)},
+		{WithChecksum(), WithCounterEncoding(CounterLittleEndian), WithCounterWidth(4)},
+		{WithoutLeadingZeros(), WithCounterEncoding(CounterDecimalASCII)},
+		{WithAlgorithm(SHA512), WithCounterPrefix([]byte("salt")), WithStepMultiplier(10)},
+		{WithEpoch(time.Unix(-86400, 0))},
+	}
+	for i, opts := range configs {
+		o, err := New(rfc6238Secret, opts...)
+		if err != nil {
+			t.Fatalf("config %d: unexpected error: %v", i, err)
+		}
+		data, err := o.MarshalBinary()
+		if err != nil {
+			t.Fatalf("config %d: unexpected error: %v", i, err)
+		}
+		var back TOTP
+		if err := back.UnmarshalBinary(data); err != nil {
+			t.Fatalf("config %d: unexpected error: %v", i, err)
+		}
+		if !SameParams(o, &back) || !bytes.Equal(o.secret, back.secret) {
+			t.Fatalf("config %d: round-trip changed parameters", i)
+		}
+		for _, ts := range []int64{59, 1111111109, 2000000000} {
+			a, _ := o.TokenAt(time.Unix(ts, 0))
+			b, _ := back.TokenAt(time.Unix(ts, 0))
+			if a != b {
+				t.Fatalf("config %d T=%d: codes differ: %q vs %q", i, ts, a, b)
+			}
+		}
+
+		// Every truncation is rejected, as is trailing data
+		for n := range len(data) {
+			if err := new(TOTP).UnmarshalBinary(data[:n]); err == nil {
+				t.Fatalf("config %d: %d of %d bytes decoded", i, n, len(data))
+			}
+		}
+		if err := new(TOTP).UnmarshalBinary(append(data, 0)); !errors.Is(err, ErrMalformedBinary) {
+			t.Fatalf("config %d: trailing byte: got %v, want ErrMalformedBinary", i, err)
+		}
+	}
+
+	// The default configuration with a 20-byte secret fits in 31 bytes
+	o, _ := New(rfc6238Secret)
+	if data, _ := o.MarshalBinary(); len(data) != 31 {
+		t.Fatalf("got %d bytes, want 31", len(data))
+	}
+	data, _ := o.MarshalBinary()
+	data[0] = 2
+	if err := new(TOTP).UnmarshalBinary(data); !errors.Is(err, ErrMalformedBinary) {
+		t.Fatalf("unknown version: got %v, want ErrMalformedBinary", err)
+	}
+	data[0], data[2] = binaryVersion, 0
+	if err := new(TOTP).UnmarshalBinary(data); err == nil {
+		t.Fatal("expected error for zero digits")
+	}
+}
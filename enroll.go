@@ -0,0 +1,55 @@
+package totp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+)
+
+// GenerateSecret
+// Mint a random secret as long as the SHA-1 HMAC output (20 bytes, RFC 4226
+// section 4), in canonical base32
+func GenerateSecret() (string, error) {
+	return generateSecret(SHA1.size())
+}
+
+// generateSecret mints n random bytes and encodes them as base32
+func generateSecret(n int) (string, error) {
+	secretBytes := make([]byte, n)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return EncodeSecret(secretBytes), nil
+}
+
+// Enroll
+// One-call enrollment: mint a secret sized for the configured algorithm,
+// build the provisioning URI and render it as a PNG QR code. Options are
+// validated like New, so WithAllowedAlgorithms applies, and must be
+// expressible in a URI (see TOTP.URI). When no QR encoder is registered the
+// uri and secret are still returned, with a nil png and an error wrapping
+// ErrQRUnavailable, so callers can fall back to manual entry.
+func Enroll(issuer, account string, opts ...Option) (uri string, png []byte, secret string, err error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return "", nil, "", err
+	}
+	secret, err = generateSecret(c.algorithm.size())
+	if err != nil {
+		return "", nil, "", err
+	}
+	o, err := New(secret, opts...)
+	if err != nil {
+		return "", nil, "", err
+	}
+	uri, err = o.URI(issuer, account)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteQR(&buf, uri); err != nil {
+		return uri, nil, secret, fmt.Errorf("render QR: %w", err)
+	}
+	return uri, buf.Bytes(), secret, nil
+}
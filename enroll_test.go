@@ -0,0 +1,79 @@
+package totp
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func Test_GenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := GenerateSecret()
+	secretBytes, err := decodeSecret(a)
+	if err != nil || len(secretBytes) != 20 {
+		t.Fatalf("got %d bytes, %v; want 20", len(secretBytes), err)
+	}
+	if a == b {
+		t.Fatalf("two secrets were equal: %q", a)
+	}
+}
+
+func Test_Enroll(t *testing.T) {
+	uri, png, secret, err := Enroll("ACME", "alice@example.com")
+	if !errors.Is(err, ErrQRUnavailable) || png != nil {
+		t.Fatalf("without an encoder: got png=%v err=%v, want ErrQRUnavailable", png, err)
+	}
+
+	k, err := ParseURI(uri)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", uri, err)
+	}
+	if k.Issuer != "ACME" || k.Account != "alice@example.com" || k.Secret != secret {
+		t.Fatalf("round-trip mismatch: %+v", k)
+	}
+	at := time.Unix(1111111109, 0)
+	code, err := GetTokenAt(secret, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, err := ValidateAt(secret, code, at, 0); err != nil || !ok {
+		t.Fatalf("secret does not validate its own code: (%v, %v)", ok, err)
+	}
+}
+
+func Test_Enroll_QR(t *testing.T) {
+	RegisterQREncoder(func(w io.Writer, content string) error {
+		_, err := io.WriteString(w, "png:"+content)
+		return err
+	})
+	t.Cleanup(func() { RegisterQREncoder(nil) })
+
+	uri, png, _, err := Enroll("ACME", "alice", WithAlgorithm(SHA256), WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(png) != "png:"+uri {
+		t.Fatalf("got png %q for uri %q", png, uri)
+	}
+	k, err := ParseURI(uri)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", uri, err)
+	}
+	secretBytes, _ := decodeSecret(k.Secret)
+	if k.Algorithm != SHA256 || k.Digits != 8 || len(secretBytes) != 32 {
+		t.Fatalf("got %v, %d digits, %d-byte secret; want SHA256, 8, 32", k.Algorithm, k.Digits, len(secretBytes))
+	}
+}
+
+func Test_Enroll_Policy(t *testing.T) {
+	if _, _, _, err := Enroll("ACME", "alice", WithAllowedAlgorithms(SHA256)); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Fatalf("got %v, want ErrAlgorithmNotAllowed", err)
+	}
+	if _, _, _, err := Enroll("ACME", "alice", WithChecksum()); !errors.Is(err, ErrNotExportable) {
+		t.Fatalf("got %v, want ErrNotExportable", err)
+	}
+}
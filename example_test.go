@@ -0,0 +1,36 @@
+package totp_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yousysadmin/totp"
+)
+
+// Collect the offsets accepted codes match at, then pick the smallest skew
+// that would still have accepted 99% of them.
+func ExampleOffsetHistogram() {
+	h := &totp.OffsetHistogram{}
+	v, err := totp.NewVerifier("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", totp.WithSkew(3), totp.WithMetrics(h))
+	if err != nil {
+		panic(err)
+	}
+
+	// Simulated logins: most clocks are in sync, one lags a window behind
+	now := time.Unix(1111111109, 0)
+	for _, lag := range []time.Duration{0, 0, 0, 30 * time.Second} {
+		code, _ := totp.GetTokenAt("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", now.Add(-lag))
+		if _, err := v.AcceptAt(code, now); err != nil {
+			panic(err)
+		}
+		now = now.Add(time.Minute)
+	}
+
+	fmt.Println(h.Counts())
+	fmt.Println("skew for 75%:", h.SkewFor(0.75))
+	fmt.Println("skew for 99%:", h.SkewFor(0.99))
+	// Output:
+	// map[-1:1 0:3]
+	// skew for 75%: 0
+	// skew for 99%: 1
+}
@@ -0,0 +1,85 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// fastToken
+// Specialized generation for the default parameters (6 digits, HMAC-SHA1,
+// 30s period). It skips the generalized formatting: the digits are written
+// into a fixed buffer instead of going through fmt, and the digest is summed
+// into a preallocated array. It must produce exactly what the generalized
+// path produces.
+func fastToken(secretBytes []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secretBytes)
+	mac.Write(msg[:])
+	var sum [sha1.Size]byte
+	code := dynamicTruncate(mac.Sum(sum[:0])) % digitsModulo
+
+	var buf [6]byte
+	writeDigits(buf[:], code)
+	return string(buf[:])
+}
+
+// writeDigits
+// Write code into buf as zero-padded ASCII digits filling all of buf
+func writeDigits(buf []byte, code uint32) {
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = byte('0' + code%10)
+		code /= 10
+	}
+}
+
+// VerifyFast
+// Check a 6-digit token against the current time with skew windows on each
+// side, like Validate, but optimized for the verify path: the secret is
+// decoded once, one keyed HMAC is created per call and reset between
+// windows, and the counter, digest and digit buffers are reused across
+// windows instead of being allocated for each. Every window is compared in
+// constant time, even after a match. The token is cleaned as Validate
+// cleans it, so grouped input such as "081 804" is accepted.
+func VerifyFast(secretKey, token string, skew int) (bool, error) {
+	if err := checkSkew(skew); err != nil {
+		return false, err
+	}
+	token, err := cleanToken(token)
+	if err != nil {
+		return false, err
+	}
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		msg  [8]byte
+		sum  [sha1.Size]byte
+		buf  [6]byte
+		want = []byte(token)
+	)
+	now, err := CounterAt(timeNow())
+	if err != nil {
+		return false, err
+	}
+	current := int64(now)
+	mac := hmac.New(sha1.New, secretBytes)
+	matched := 0
+	for offset := -skew; offset <= skew; offset++ {
+		counter := current + int64(offset)
+		if counter < 0 {
+			continue
+		}
+		mac.Reset()
+		binary.BigEndian.PutUint64(msg[:], uint64(counter))
+		mac.Write(msg[:])
+		writeDigits(buf[:], dynamicTruncate(mac.Sum(sum[:0]))%digitsModulo)
+		matched |= subtle.ConstantTimeCompare(buf[:], want)
+	}
+	return matched == 1, nil
+}
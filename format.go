@@ -0,0 +1,125 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// FormatOptions
+// Control how a code is split for display
+type FormatOptions struct {
+	// GroupSize is the number of digits per group. When it does not divide
+	// the code length, groups are filled from the left and the last one is
+	// shorter: 4 on "12345678" gives "1234 5678", 4 on "123456" gives "1234 56".
+	GroupSize int
+	// Separator is placed between groups
+	Separator string
+}
+
+// DefaultFormatOptions
+// Return the usual grouping for a code length: two halves separated by a
+// space, the first one longer for odd lengths, so "081 804" for six digits
+// and "9428 7082" for eight
+func DefaultFormatOptions(digits int) FormatOptions {
+	return FormatOptions{GroupSize: max((digits+1)/2, 1), Separator: " "}
+}
+
+// ErrInvalidGroupSize is returned when FormatOptions.GroupSize is not positive
+var ErrInvalidGroupSize = errors.New("group size must be positive")
+
+// FormatToken
+// Split a code into groups for display
+func FormatToken(code string, opts FormatOptions) (string, error) {
+	if opts.GroupSize <= 0 {
+		return "", ErrInvalidGroupSize
+	}
+	var b strings.Builder
+	for i := 0; i < len(code); i += opts.GroupSize {
+		if i > 0 {
+			b.WriteString(opts.Separator)
+		}
+		b.WriteString(code[i:min(i+opts.GroupSize, len(code))])
+	}
+	return b.String(), nil
+}
+
+// GetTokenFormatted
+// Generate the code for time t and format it for display
+func GetTokenFormatted(secretKey string, t time.Time, opts FormatOptions) (string, error) {
+	if opts.GroupSize <= 0 {
+		return "", ErrInvalidGroupSize
+	}
+	code, err := GetTokenAt(secretKey, t)
+	if err != nil {
+		return "", err
+	}
+	return FormatToken(code, opts)
+}
+
+// UnformatToken
+// Undo FormatToken: remove the group separators, spaces or dashes, and
+// return the bare code, failing with ErrNonDigitToken if anything but
+// digits is left. Verification does this itself, so grouped input can be
+// passed to it directly.
+func UnformatToken(s string) (string, error) {
+	return cleanToken(s)
+}
+
+// defaultDescribeTemplate is the DescribeToken output format
+var defaultDescribeTemplate = template.Must(template.New("describe").Parse("{{.Code}} (valid for {{.Remaining}}s)"))
+
+// Description
+// The values available to a DescribeToken template
+type Description struct {
+	Code string
+	// Remaining is the number of seconds the code stays valid
+	Remaining int
+}
+
+// DescribeOption
+// Configure DescribeToken
+type DescribeOption func(*describeConfig) error
+
+// describeConfig holds the settings collected from describe options
+type describeConfig struct {
+	tmpl *template.Template
+}
+
+// WithDescribeTemplate
+// Format the line with a text/template over Description instead of the
+// default "{{.Code}} (valid for {{.Remaining}}s)"
+func WithDescribeTemplate(text string) DescribeOption {
+	return func(c *describeConfig) error {
+		tmpl, err := template.New("describe").Parse(text)
+		if err != nil {
+			return err
+		}
+		c.tmpl = tmpl
+		return nil
+	}
+}
+
+// DescribeToken
+// Return the current code with its remaining validity as one line ready to
+// print, "081804 (valid for 17s)" by default, for CLI tools
+func DescribeToken(secretKey string, opts ...DescribeOption) (string, error) {
+	c := describeConfig{tmpl: defaultDescribeTemplate}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return "", fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	t := timeNow()
+	code, err := GetTokenAt(secretKey, t)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := c.tmpl.Execute(&b, Description{Code: code, Remaining: RemainingSeconds(t)}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,88 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_GetTokenFormatted(t *testing.T) {
+	got, err := GetTokenFormatted(rfc6238Secret, time.Unix(1111111109, 0), FormatOptions{GroupSize: 3, Separator: "-"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "081-804" {
+		t.Fatalf("got %q, want %q", got, "081-804")
+	}
+}
+
+func Test_UnformatToken(t *testing.T) {
+	for _, opts := range []FormatOptions{{GroupSize: 3, Separator: " "}, {GroupSize: 3, Separator: "-"}, {GroupSize: 2, Separator: " - "}} {
+		grouped, _ := FormatToken("081804", opts)
+		if got, err := UnformatToken(grouped); err != nil || got != "081804" {
+			t.Fatalf("%q: got (%q, %v), want %q", grouped, got, err, "081804")
+		}
+		if ok, err := ValidateAt(rfc6238Secret, grouped, time.Unix(1111111109, 0), 0); err != nil || !ok {
+			t.Fatalf("%q: got (%v, %v), want (true, nil)", grouped, ok, err)
+		}
+	}
+	if _, err := UnformatToken("081.804"); !errors.Is(err, ErrNonDigitToken) {
+		t.Fatalf("got %v, want ErrNonDigitToken", err)
+	}
+}
+
+func Test_FormatToken(t *testing.T) {
+	cases := []struct {
+		code string
+		opts FormatOptions
+		want string
+	}{
+		{code: "081804", opts: FormatOptions{GroupSize: 3, Separator: " "}, want: "081 804"},
+		{code: "081804", opts: FormatOptions{GroupSize: 2, Separator: "."}, want: "08.18.04"},
+		{code: "081804", opts: FormatOptions{GroupSize: 4, Separator: " "}, want: "0818 04"},
+		{code: "081804", opts: FormatOptions{GroupSize: 6, Separator: "-"}, want: "081804"},
+		{code: "081804", opts: FormatOptions{GroupSize: 10, Separator: "-"}, want: "081804"},
+	}
+	for _, tc := range cases {
+		got, err := FormatToken(tc.code, tc.opts)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", tc.opts, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%+v: got %q, want %q", tc.opts, got, tc.want)
+		}
+	}
+}
+
+func Test_FormatToken_InvalidGroupSize(t *testing.T) {
+	if _, err := FormatToken("081804", FormatOptions{}); !errors.Is(err, ErrInvalidGroupSize) {
+		t.Fatalf("got %v, want ErrInvalidGroupSize", err)
+	}
+}
+
+func Test_DescribeToken(t *testing.T) {
+	// 1111111093 leaves 17s of the window ending at 1111111110
+	pinTime(t, time.Unix(1111111093, 0))
+	got, err := DescribeToken(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "081804 (valid for 17s)" {
+		t.Fatalf("got %q, want %q", got, "081804 (valid for 17s)")
+	}
+
+	got, err = DescribeToken(rfc6238Secret, WithDescribeTemplate("{{.Remaining}}s left: {{.Code}}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "17s left: 081804" {
+		t.Fatalf("got %q, want %q", got, "17s left: 081804")
+	}
+
+	if _, err := DescribeToken(rfc6238Secret, WithDescribeTemplate("{{.Code")); err == nil {
+		t.Fatal("expected error for an invalid template")
+	}
+	if _, err := DescribeToken(rfc6238Secret, WithDescribeTemplate("{{.Missing}}")); err == nil {
+		t.Fatal("expected error for an unknown field")
+	}
+}
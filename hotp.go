@@ -0,0 +1,97 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxHOTPSearch is the largest counter range FindHOTPCounter scans
+const maxHOTPSearch = 100_000
+
+// ErrNegativeLookAhead is returned when an HOTP look-ahead window is below zero
+var ErrNegativeLookAhead = errors.New("look-ahead must not be negative")
+
+// HOTP
+// A decoded secret together with its generation parameters for
+// counter-based (RFC 4226) codes
+type HOTP struct {
+	o *TOTP // the period is unused
+}
+
+// NewHOTP
+// Decode the secret and apply options. WithPeriod has no effect on HOTP.
+func NewHOTP(secretKey string, opts ...Option) (*HOTP, error) {
+	o, err := New(secretKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &HOTP{o: o}, nil
+}
+
+// Token
+// Generate the code for a counter
+func (h *HOTP) Token(counter uint64) string {
+	return h.o.code(counter)
+}
+
+// GetHOTP
+// Generate the 6-digit HOTP code (RFC 4226) for a counter
+func GetHOTP(secretKey string, counter uint64) (string, error) {
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", hotp(secretBytes, counter)), nil
+}
+
+// ResyncHOTP
+// Search counters current..current+lookAhead for the token and return the
+// counter it matched
+func ResyncHOTP(secretKey, token string, current uint64, lookAhead int) (matched uint64, ok bool, err error) {
+	if lookAhead < 0 {
+		return 0, false, ErrNegativeLookAhead
+	}
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := 0; i <= lookAhead; i++ {
+		counter := current + uint64(i)
+		code := fmt.Sprintf("%06d", hotp(secretBytes, counter))
+		if subtle.ConstantTimeCompare([]byte(code), []byte(token)) == 1 {
+			return counter, true, nil
+		}
+		if counter == math.MaxUint64 {
+			break
+		}
+	}
+	return 0, false, nil
+}
+
+// AdvanceHOTP
+// Verify the token like ResyncHOTP and return the counter to persist for the
+// next verification (matched+1). On failure next equals current, so storing
+// it unconditionally is safe.
+func AdvanceHOTP(secretKey, token string, current uint64, lookAhead int) (next uint64, ok bool, err error) {
+	matched, ok, err := ResyncHOTP(secretKey, token, current, lookAhead)
+	if err != nil || !ok {
+		return current, false, err
+	}
+	return matched + 1, true, nil
+}
+
+// FindHOTPCounter
+// Forensic search: scan the inclusive counter range [start, end] for the
+// token and return the counter it matched. Unlike ResyncHOTP this searches a
+// recorded historical range; it is capped at maxHOTPSearch counters.
+func FindHOTPCounter(secretKey, token string, start, end uint64) (uint64, bool, error) {
+	if end < start {
+		return 0, false, fmt.Errorf("invalid counter range [%d, %d]", start, end)
+	}
+	if end-start >= maxHOTPSearch {
+		return 0, false, fmt.Errorf("counter range of %d exceeds the limit of %d", end-start+1, maxHOTPSearch)
+	}
+	return ResyncHOTP(secretKey, token, start, int(end-start))
+}
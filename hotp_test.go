@@ -0,0 +1,103 @@
+package totp
+
+import (
+	"testing"
+)
+
+// RFC 4226 Appendix D HOTP values for the same ASCII seed
+var rfc4226Codes = []string{
+	"755224", "287082", "359152", "969429", "338314",
+	"254676", "287922", "162583", "399871", "520489",
+}
+
+func Test_GetHOTP_RFC4226(t *testing.T) {
+	for counter, want := range rfc4226Codes {
+		got, err := GetHOTP(rfc6238Secret, uint64(counter))
+		if err != nil {
+			t.Fatalf("counter=%d: unexpected error: %v", counter, err)
+		}
+		if got != want {
+			t.Fatalf("counter=%d: got %q, want %q", counter, got, want)
+		}
+	}
+}
+
+func Test_NewHOTP(t *testing.T) {
+	h, err := NewHOTP(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for counter, want := range rfc4226Codes {
+		if got := h.Token(uint64(counter)); got != want {
+			t.Fatalf("counter=%d: got %q, want %q", counter, got, want)
+		}
+	}
+	if _, err := NewHOTP("not*base32"); err == nil {
+		t.Fatalf("expected error for an invalid secret")
+	}
+}
+
+func Test_ResyncHOTP(t *testing.T) {
+	matched, ok, err := ResyncHOTP(rfc6238Secret, rfc4226Codes[5], 2, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok || matched != 5 {
+		t.Fatalf("got (%d, %v), want (5, true)", matched, ok)
+	}
+
+	// Counter 5 is out of reach from 2 with a look-ahead of 2
+	if _, ok, _ := ResyncHOTP(rfc6238Secret, rfc4226Codes[5], 2, 2); ok {
+		t.Fatal("expected code beyond look-ahead to be rejected")
+	}
+
+	if _, _, err := ResyncHOTP(rfc6238Secret, rfc4226Codes[5], 2, -1); err == nil {
+		t.Fatal("expected error for negative look-ahead, got nil")
+	}
+}
+
+func Test_AdvanceHOTP_SkipAhead(t *testing.T) {
+	// The user pressed the button three extra times: stored 4, token for 7
+	next, ok, err := AdvanceHOTP(rfc6238Secret, rfc4226Codes[7], 4, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok || next != 8 {
+		t.Fatalf("got (%d, %v), want (8, true)", next, ok)
+	}
+
+	next, ok, err = AdvanceHOTP(rfc6238Secret, "000000", 4, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok || next != 4 {
+		t.Fatalf("got (%d, %v), want (4, false)", next, ok)
+	}
+}
+
+func Test_FindHOTPCounter(t *testing.T) {
+	counter, ok, err := FindHOTPCounter(rfc6238Secret, rfc4226Codes[6], 0, 9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok || counter != 6 {
+		t.Fatalf("got (%d, %v), want (6, true)", counter, ok)
+	}
+
+	// Range bounds are inclusive
+	if c, ok, _ := FindHOTPCounter(rfc6238Secret, rfc4226Codes[9], 9, 9); !ok || c != 9 {
+		t.Fatalf("got (%d, %v), want (9, true)", c, ok)
+	}
+	if _, ok, _ := FindHOTPCounter(rfc6238Secret, rfc4226Codes[6], 0, 5); ok {
+		t.Fatal("expected counter outside the range to be missed")
+	}
+}
+
+func Test_FindHOTPCounter_InvalidRange(t *testing.T) {
+	if _, _, err := FindHOTPCounter(rfc6238Secret, "755224", 5, 4); err == nil {
+		t.Fatal("expected error for reversed range, got nil")
+	}
+	if _, _, err := FindHOTPCounter(rfc6238Secret, "755224", 0, maxHOTPSearch); err == nil {
+		t.Fatal("expected error for oversized range, got nil")
+	}
+}
@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// maxTableWindows bounds GenerateTable (about 3.5 days of 30s windows)
+const maxTableWindows = 10_000
+
+// ErrRangeTooLarge is returned when a requested range spans too many windows
+var ErrRangeTooLarge = errors.New("time range spans too many windows")
+
+// Codes
+// Yield (window start, code) pairs lazily, from the window containing start
+// onward, without end. The secret is decoded once; an invalid secret or a
+// pre-epoch start yields nothing, so check the secret beforehand when the
+// distinction matters.
+func Codes(secretKey string, start time.Time) iter.Seq2[time.Time, string] {
+	o, err := New(secretKey)
+	if err != nil {
+		return func(func(time.Time, string) bool) {}
+	}
+	return o.Codes(start)
+}
+
+// Codes
+// Yield (window start, code) pairs lazily, from the window containing start
+// onward, without end. A pre-epoch start yields nothing.
+func (o *TOTP) Codes(start time.Time) iter.Seq2[time.Time, string] {
+	return func(yield func(time.Time, string) bool) {
+		counter, err := o.counterAt(start.Unix())
+		if err != nil {
+			return
+		}
+		for ; ; counter++ {
+			windowStart := time.Unix(o.windowStart(counter), 0).UTC()
+			if !yield(windowStart, o.code(counter)) {
+				return
+			}
+		}
+	}
+}
+
+// GenerateTable
+// Diagnostic: list every window overlapping [start, end), from the one
+// containing start, with its code. At most 10000 windows are returned;
+// larger ranges fail with ErrRangeTooLarge.
+func GenerateTable(secretKey string, start, end time.Time) ([]Tick, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("end %v is before start %v", end, start)
+	}
+	if end.Equal(start) {
+		return nil, nil
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	first, err := o.counterAt(start.Unix())
+	if err != nil {
+		return nil, err
+	}
+	// Windows overlapping the range start before end, rounded up to a second
+	endTs := end.Unix()
+	if end.Nanosecond() > 0 {
+		endTs++
+	}
+	n := (endTs+o.step()-1)/o.step() - int64(first)
+	if n > maxTableWindows {
+		return nil, fmt.Errorf("%w: %d windows, at most %d", ErrRangeTooLarge, n, maxTableWindows)
+	}
+
+	table := make([]Tick, 0, n)
+	for windowStart, code := range o.Codes(start) {
+		if !windowStart.Before(end) {
+			break
+		}
+		table = append(table, Tick{WindowStart: windowStart, Code: code})
+	}
+	return table, nil
+}
+
+// DayTokens
+// List every window of the UTC day containing day, from midnight, for
+// offline caching: 2880 entries with the 30s period
+func DayTokens(secretKey string, day time.Time) ([]Tick, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	return GenerateTable(secretKey, start, start.Add(24*time.Hour))
+}
+
+// SampleTokens
+// Generate the codes at start, start+step, ... (count times), decoding the
+// secret once, for test fixtures and load generators. At most 10000
+// samples are returned; larger counts fail with ErrRangeTooLarge.
+func SampleTokens(secretKey string, start time.Time, step time.Duration, count int) ([]string, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("count must not be negative, got %d", count)
+	}
+	if count > maxTableWindows {
+		return nil, fmt.Errorf("%w: %d samples, at most %d", ErrRangeTooLarge, count, maxTableWindows)
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, count)
+	for i := range codes {
+		if codes[i], err = o.TokenAt(start.Add(time.Duration(i) * step)); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
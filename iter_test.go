@@ -0,0 +1,142 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Codes(t *testing.T) {
+	start := time.Unix(1111111100, 0) // mid-window
+	want := int64(1111111080)
+	n := 0
+	for windowStart, code := range Codes(rfc6238Secret, start) {
+		if windowStart.Unix() != want {
+			t.Fatalf("pair %d: window start %d, want %d", n, windowStart.Unix(), want)
+		}
+		expected, err := GetTokenAt(rfc6238Secret, windowStart)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if code != expected {
+			t.Fatalf("pair %d: got %q, want %q", n, code, expected)
+		}
+		want += 30
+		n++
+		if n == 5 {
+			break
+		}
+	}
+	if n != 5 {
+		t.Fatalf("got %d pairs, want 5", n)
+	}
+}
+
+func Test_Codes_InvalidSecret(t *testing.T) {
+	for range Codes("not*base32==", time.Unix(59, 0)) {
+		t.Fatal("expected no pairs for an invalid secret")
+	}
+}
+
+func Test_GenerateTable(t *testing.T) {
+	// [1111111100, 1111111170) overlaps the windows starting at
+	// 1111111080, 1111111110 and 1111111140
+	table, err := GenerateTable(rfc6238Secret, time.Unix(1111111100, 0), time.Unix(1111111170, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		start int64
+		code  string
+	}{
+		{1111111080, "081804"},
+		{1111111110, "050471"},
+		{1111111140, "266759"},
+	}
+	if len(table) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(table), len(want), table)
+	}
+	for i, w := range want {
+		if table[i].WindowStart.Unix() != w.start || table[i].Code != w.code {
+			t.Fatalf("row %d: got (%d, %q), want (%d, %q)", i, table[i].WindowStart.Unix(), table[i].Code, w.start, w.code)
+		}
+	}
+
+	// An end exactly on a boundary excludes the window starting there
+	table, _ = GenerateTable(rfc6238Secret, time.Unix(1111111080, 0), time.Unix(1111111110, 0))
+	if len(table) != 1 {
+		t.Fatalf("boundary end: got %d rows, want 1", len(table))
+	}
+	if table, err := GenerateTable(rfc6238Secret, time.Unix(100, 0), time.Unix(100, 0)); err != nil || len(table) != 0 {
+		t.Fatalf("empty range: got %v, %v", table, err)
+	}
+}
+
+func Test_GenerateTable_Bounds(t *testing.T) {
+	start := time.Unix(1111111080, 0)
+	if _, err := GenerateTable(rfc6238Secret, start, start.Add(maxTableWindows*30*time.Second)); err != nil {
+		t.Fatalf("largest range: unexpected error: %v", err)
+	}
+	if _, err := GenerateTable(rfc6238Secret, start, start.Add(maxTableWindows*30*time.Second+time.Second)); !errors.Is(err, ErrRangeTooLarge) {
+		t.Fatalf("got %v, want ErrRangeTooLarge", err)
+	}
+	if _, err := GenerateTable(rfc6238Secret, start, start.Add(-time.Second)); err == nil {
+		t.Fatalf("expected error for end before start")
+	}
+}
+
+func Test_DayTokens(t *testing.T) {
+	// 2005-03-18 01:58:29 UTC, in a UTC+9 location where it is already
+	// 10:58 the same morning: the UTC day is returned either way
+	at := time.Unix(1111111109, 0).In(time.FixedZone("UTC+9", 9*3600))
+	table, err := DayTokens(rfc6238Secret, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(table) != 2880 {
+		t.Fatalf("got %d entries, want 2880", len(table))
+	}
+	midnight := time.Date(2005, 3, 18, 0, 0, 0, 0, time.UTC)
+	if !table[0].WindowStart.Equal(midnight) {
+		t.Fatalf("first window starts at %v, want %v", table[0].WindowStart, midnight)
+	}
+	if last := table[len(table)-1].WindowStart; !last.Equal(midnight.Add(24*time.Hour - 30*time.Second)) {
+		t.Fatalf("last window starts at %v", last)
+	}
+	for _, tick := range table {
+		if tick.WindowStart.Unix() == 1111111080 && tick.Code != "081804" {
+			t.Fatalf("got %q, want %q for the RFC window", tick.Code, "081804")
+		}
+	}
+}
+
+func Test_SampleTokens(t *testing.T) {
+	start := time.Unix(1111111000, 0)
+	step := 17 * time.Second
+	codes, err := SampleTokens(rfc6238Secret, start, step, 200)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != 200 {
+		t.Fatalf("got %d codes, want 200", len(codes))
+	}
+	for i, code := range codes {
+		want, _ := GetTokenAt(rfc6238Secret, start.Add(time.Duration(i)*step))
+		if code != want {
+			t.Fatalf("sample %d: got %q, want %q", i, code, want)
+		}
+	}
+
+	if codes, err := SampleTokens(rfc6238Secret, start, step, 0); err != nil || len(codes) != 0 {
+		t.Fatalf("zero count: got %v, %v", codes, err)
+	}
+	if _, err := SampleTokens(rfc6238Secret, start, step, maxTableWindows+1); !errors.Is(err, ErrRangeTooLarge) {
+		t.Fatalf("got %v, want ErrRangeTooLarge", err)
+	}
+	if _, err := SampleTokens(rfc6238Secret, time.Unix(10, 0), -10*time.Second, 3); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("got %v, want ErrTimeBeforeEpoch", err)
+	}
+	if _, err := SampleTokens("not base32!", start, step, 1); err == nil {
+		t.Fatal("expected error for an invalid secret")
+	}
+}
@@ -0,0 +1,96 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLockedOut is returned by LimitedVerifier while attempts are blocked
+var ErrLockedOut = errors.New("too many attempts, locked out")
+
+// AttemptStore
+// Persist attempt counters and lockouts for a LimitedVerifier, e.g. in
+// Redis. Keys never contain the secret. Implementations must be safe for
+// concurrent use.
+type AttemptStore interface {
+	// AddAttempt records one attempt under key and returns the number of
+	// attempts recorded under it so far. The entry may be dropped after
+	// expiresAt.
+	AddAttempt(key string, expiresAt time.Time) (int, error)
+	// SetLockout blocks key until the given time.
+	SetLockout(key string, until time.Time) error
+	// Lockout returns the time key is blocked until; the zero time if none.
+	Lockout(key string) (time.Time, error)
+}
+
+// LimitedVerifier
+// Wrap a Verifier with a per-window attempt limit to resist online
+// brute force. Once more than maxAttempts tokens are submitted within one
+// window, every attempt fails with ErrLockedOut until the lockout ends, even
+// for an otherwise valid code.
+type LimitedVerifier struct {
+	verifier    *Verifier
+	store       AttemptStore
+	maxAttempts int
+	lockout     time.Duration
+	key         string
+}
+
+// NewLimitedVerifier
+// Limit v to maxAttempts per window, locking out for lockout once exceeded
+func NewLimitedVerifier(v *Verifier, store AttemptStore, maxAttempts int, lockout time.Duration) (*LimitedVerifier, error) {
+	if v == nil || store == nil {
+		return nil, errors.New("verifier and store must not be nil")
+	}
+	if maxAttempts <= 0 {
+		return nil, fmt.Errorf("max attempts must be positive, got %d", maxAttempts)
+	}
+	if lockout <= 0 {
+		return nil, fmt.Errorf("lockout must be positive, got %v", lockout)
+	}
+	// Key the store by a fingerprint of the primary secret, not the secret
+	return &LimitedVerifier{
+		verifier:    v,
+		store:       store,
+		maxAttempts: maxAttempts,
+		lockout:     lockout,
+		key:         fingerprint(v.primary.secret),
+	}, nil
+}
+
+// Accept
+// Check the token against the current time
+func (l *LimitedVerifier) Accept(token string) (bool, error) {
+	return l.AcceptAt(token, l.verifier.primary.now())
+}
+
+// AcceptAt
+// Check the token at time t, counting the attempt against the limit
+func (l *LimitedVerifier) AcceptAt(token string, t time.Time) (bool, error) {
+	until, err := l.store.Lockout(l.key)
+	if err != nil {
+		return false, err
+	}
+	if t.Before(until) {
+		return false, ErrLockedOut
+	}
+
+	p := l.verifier.primary
+	counter, err := p.counterAt(t.Unix())
+	if err != nil {
+		return false, err
+	}
+	windowEnd := time.Unix(p.windowStart(counter+1), 0).UTC()
+	n, err := l.store.AddAttempt(fmt.Sprintf("%s:%d", l.key, counter), windowEnd)
+	if err != nil {
+		return false, err
+	}
+	if n > l.maxAttempts {
+		if err := l.store.SetLockout(l.key, t.Add(l.lockout)); err != nil {
+			return false, err
+		}
+		return false, ErrLockedOut
+	}
+	return l.verifier.AcceptAt(token, t)
+}
@@ -0,0 +1,106 @@
+package totp
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapAttemptStore is a minimal AttemptStore for tests; it ignores expiry
+type mapAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]int
+	lockouts map[string]time.Time
+}
+
+func newMapAttemptStore() *mapAttemptStore {
+	return &mapAttemptStore{attempts: map[string]int{}, lockouts: map[string]time.Time{}}
+}
+
+func (s *mapAttemptStore) AddAttempt(key string, _ time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[key]++
+	return s.attempts[key], nil
+}
+
+func (s *mapAttemptStore) SetLockout(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockouts[key] = until
+	return nil
+}
+
+func (s *mapAttemptStore) Lockout(key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lockouts[key], nil
+}
+
+func Test_LimitedVerifier_Lockout(t *testing.T) {
+	v, err := NewVerifier(rfc6238Secret, WithSkew(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l, err := NewLimitedVerifier(v, newMapAttemptStore(), 3, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	at := time.Unix(1111111100, 0)
+	for i := range 3 {
+		ok, err := l.AcceptAt("000000", at)
+		if err != nil || ok {
+			t.Fatalf("attempt %d: got (%v, %v), want (false, nil)", i+1, ok, err)
+		}
+	}
+
+	// Fourth attempt in the same window: locked out even with the right code
+	if _, err := l.AcceptAt("081804", at); !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("got %v, want ErrLockedOut", err)
+	}
+	// Still locked out in the next window
+	if _, err := l.AcceptAt("081804", at.Add(30*time.Second)); !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("got %v, want ErrLockedOut", err)
+	}
+
+	// After the lockout a fresh window accepts valid codes again
+	after := at.Add(2 * time.Minute)
+	code, _ := GetTokenAt(rfc6238Secret, after)
+	ok, err := l.AcceptAt(code, after)
+	if err != nil || !ok {
+		t.Fatalf("after lockout: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_LimitedVerifier_UnderLimit(t *testing.T) {
+	v, err := NewVerifier(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l, err := NewLimitedVerifier(v, newMapAttemptStore(), 2, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	at := time.Unix(1111111100, 0)
+	if ok, err := l.AcceptAt("000000", at); err != nil || ok {
+		t.Fatalf("got (%v, %v), want (false, nil)", ok, err)
+	}
+	if ok, err := l.AcceptAt("081804", at); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_NewLimitedVerifier_Invalid(t *testing.T) {
+	v, _ := NewVerifier(rfc6238Secret)
+	if _, err := NewLimitedVerifier(v, nil, 3, time.Minute); err == nil {
+		t.Fatal("expected error for nil store, got nil")
+	}
+	if _, err := NewLimitedVerifier(v, newMapAttemptStore(), 0, time.Minute); err == nil {
+		t.Fatal("expected error for zero attempts, got nil")
+	}
+	if _, err := NewLimitedVerifier(v, newMapAttemptStore(), 3, 0); err == nil {
+		t.Fatal("expected error for zero lockout, got nil")
+	}
+}
@@ -0,0 +1,104 @@
+package totp
+
+import (
+	"maps"
+	"sync"
+)
+
+// Metrics
+// Receive counters from a Verifier. Implementations adapt these calls to
+// Prometheus, OpenTelemetry or similar; they must be safe for concurrent use.
+type Metrics interface {
+	// IncValidation is called once for every token checked by the Verifier,
+	// whether or not it matches. Calls rejected before checking (e.g. an
+	// empty token) are not counted.
+	IncValidation()
+	// IncMatch is called once for every token that was accepted.
+	IncMatch()
+	// ObserveOffset is called after IncMatch with the window offset the
+	// token matched at: 0 for the current window, negative for past windows
+	// (a slow or lagging client clock) and positive for future windows (a
+	// client clock running ahead).
+	ObserveOffset(offset int)
+}
+
+// noopMetrics is the default Metrics and discards everything
+type noopMetrics struct{}
+
+func (noopMetrics) IncValidation()    {}
+func (noopMetrics) IncMatch()         {}
+func (noopMetrics) ObserveOffset(int) {}
+
+// OffsetHistogram
+// A Metrics that tallies the offsets tokens matched at, to pick the
+// smallest skew that still accepts most users. Install it with WithMetrics
+// on a Verifier with a generous skew, collect for a while, then read
+// SkewFor. It is safe for concurrent use.
+type OffsetHistogram struct {
+	mu          sync.Mutex
+	validations uint64
+	counts      map[int]uint64
+}
+
+// IncValidation implements Metrics
+func (h *OffsetHistogram) IncValidation() {
+	h.mu.Lock()
+	h.validations++
+	h.mu.Unlock()
+}
+
+// IncMatch implements Metrics; matches are counted by ObserveOffset
+func (h *OffsetHistogram) IncMatch() {}
+
+// ObserveOffset implements Metrics
+func (h *OffsetHistogram) ObserveOffset(offset int) {
+	h.mu.Lock()
+	if h.counts == nil {
+		h.counts = make(map[int]uint64)
+	}
+	h.counts[offset]++
+	h.mu.Unlock()
+}
+
+// Counts
+// Return a copy of the tally: matches per offset
+func (h *OffsetHistogram) Counts() map[int]uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return maps.Clone(h.counts)
+}
+
+// Validations
+// Return the number of tokens checked, matched or not
+func (h *OffsetHistogram) Validations() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.validations
+}
+
+// SkewFor
+// Return the smallest symmetric skew that would have accepted at least the
+// given fraction (0 to 1) of the observed matches, e.g. 0.99. Zero when
+// nothing was observed.
+func (h *OffsetHistogram) SkewFor(fraction float64) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var total uint64
+	bySkew := make(map[int]uint64)
+	maxSkew := 0
+	for offset, n := range h.counts {
+		skew := max(offset, -offset)
+		bySkew[skew] += n
+		total += n
+		maxSkew = max(maxSkew, skew)
+	}
+	var covered uint64
+	for skew := 0; skew <= maxSkew; skew++ {
+		covered += bySkew[skew]
+		if float64(covered) >= fraction*float64(total) {
+			return skew
+		}
+	}
+	return maxSkew
+}
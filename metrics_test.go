@@ -0,0 +1,55 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_OffsetHistogram(t *testing.T) {
+	h := &OffsetHistogram{}
+	for offset, n := range map[int]int{0: 90, -1: 7, 1: 2, -3: 1} {
+		for range n {
+			h.IncValidation()
+			h.IncMatch()
+			h.ObserveOffset(offset)
+		}
+	}
+	h.IncValidation() // a failed validation
+
+	counts := h.Counts()
+	if counts[0] != 90 || counts[-1] != 7 || counts[1] != 2 || counts[-3] != 1 || len(counts) != 4 {
+		t.Fatalf("got counts %v", counts)
+	}
+	if h.Validations() != 101 {
+		t.Fatalf("got %d validations, want 101", h.Validations())
+	}
+	for _, tc := range []struct {
+		fraction float64
+		want     int
+	}{
+		{0.5, 0}, {0.9, 0}, {0.95, 1}, {0.99, 1}, {1, 3},
+	} {
+		if got := h.SkewFor(tc.fraction); got != tc.want {
+			t.Fatalf("SkewFor(%v): got %d, want %d", tc.fraction, got, tc.want)
+		}
+	}
+	if got := (&OffsetHistogram{}).SkewFor(0.99); got != 0 {
+		t.Fatalf("empty histogram: got %d, want 0", got)
+	}
+}
+
+func Test_OffsetHistogram_Verifier(t *testing.T) {
+	pinTime(t, time.Unix(60, 0))
+	h := &OffsetHistogram{}
+	v, err := NewVerifier(rfc6238Secret, WithSkew(2), WithMetrics(h))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// T=60 is one window after the T=59 code
+	if ok, err := v.AcceptAt("287082", time.Unix(60, 0)); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+	if got := h.Counts(); got[-1] != 1 || len(got) != 1 {
+		t.Fatalf("got counts %v, want map[-1:1]", got)
+	}
+}
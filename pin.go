@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrPINMismatch is returned by StripPIN when the input does not carry the
+// expected PIN
+var ErrPINMismatch = errors.New("PIN does not match")
+
+// PINOption
+// Configure how a PIN is combined with a code
+type PINOption func(*pinConfig)
+
+// pinConfig holds the settings collected from PIN options
+type pinConfig struct {
+	suffix bool
+}
+
+// WithPINSuffix
+// Place the PIN after the code ("081804" + "1234") instead of before it
+func WithPINSuffix() PINOption {
+	return func(c *pinConfig) {
+		c.suffix = true
+	}
+}
+
+// GetTokenWithPIN
+// Generate the current code combined with a static PIN, PIN first by
+// default ("1234" + "081804"), as some legacy VPNs expect
+func GetTokenWithPIN(secretKey, pin string, opts ...PINOption) (string, error) {
+	c, err := newPINConfig(pin, opts)
+	if err != nil {
+		return "", err
+	}
+	code, err := GetToken(secretKey)
+	if err != nil {
+		return "", err
+	}
+	if c.suffix {
+		return code + pin, nil
+	}
+	return pin + code, nil
+}
+
+// StripPIN
+// Remove the PIN from input combined as GetTokenWithPIN does (with the same
+// options) and return the code for validation. The PIN is compared in
+// constant time; input without it fails with ErrPINMismatch.
+func StripPIN(input, pin string, opts ...PINOption) (string, error) {
+	c, err := newPINConfig(pin, opts)
+	if err != nil {
+		return "", err
+	}
+	if len(input) <= len(pin) {
+		return "", ErrPINMismatch
+	}
+	got, code := input[:len(pin)], input[len(pin):]
+	if c.suffix {
+		code, got = input[:len(input)-len(pin)], input[len(input)-len(pin):]
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(pin)) != 1 {
+		return "", ErrPINMismatch
+	}
+	return code, nil
+}
+
+// newPINConfig applies PIN options and checks the PIN itself
+func newPINConfig(pin string, opts []PINOption) (pinConfig, error) {
+	if pin == "" || strings.ContainsFunc(pin, unicode.IsSpace) {
+		return pinConfig{}, errors.New("PIN must be nonempty and contain no whitespace")
+	}
+	var c pinConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c, nil
+}
@@ -0,0 +1,57 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_GetTokenWithPIN(t *testing.T) {
+	pinTime(t, time.Unix(1111111109, 0))
+
+	prefixed, err := GetTokenWithPIN(rfc6238Secret, "1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prefixed != "1234081804" {
+		t.Fatalf("got %q, want %q", prefixed, "1234081804")
+	}
+	suffixed, err := GetTokenWithPIN(rfc6238Secret, "1234", WithPINSuffix())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if suffixed != "0818041234" {
+		t.Fatalf("got %q, want %q", suffixed, "0818041234")
+	}
+	if _, err := GetTokenWithPIN(rfc6238Secret, ""); err == nil {
+		t.Fatalf("expected error for an empty PIN")
+	}
+}
+
+func Test_StripPIN(t *testing.T) {
+	code, err := StripPIN("1234081804", "1234")
+	if err != nil || code != "081804" {
+		t.Fatalf("prefix: got (%q, %v), want %q", code, err, "081804")
+	}
+	code, err = StripPIN("0818041234", "1234", WithPINSuffix())
+	if err != nil || code != "081804" {
+		t.Fatalf("suffix: got (%q, %v), want %q", code, err, "081804")
+	}
+	if ok, err := ValidateAt(rfc6238Secret, code, time.Unix(1111111109, 0), 0); err != nil || !ok {
+		t.Fatalf("stripped code does not validate: (%v, %v)", ok, err)
+	}
+
+	for _, tc := range []struct {
+		input string
+		opts  []PINOption
+	}{
+		{"9999081804", nil},
+		{"0818041234", nil}, // suffixed input checked as prefixed
+		{"1234", nil},
+		{"1234081804", []PINOption{WithPINSuffix()}},
+	} {
+		if _, err := StripPIN(tc.input, "1234", tc.opts...); !errors.Is(err, ErrPINMismatch) {
+			t.Fatalf("%q: got %v, want ErrPINMismatch", tc.input, err)
+		}
+	}
+}
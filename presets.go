@@ -0,0 +1,37 @@
+package totp
+
+import "time"
+
+// Presets bundle the options of well-known authenticator profiles, so a
+// configuration can name the profile instead of restating its parameters.
+// They can be combined with further options, which apply after them.
+
+// PresetGoogle
+// Google Authenticator: 6 digits, 30s period, SHA-1 (the defaults)
+func PresetGoogle() Option {
+	return preset(WithDigits(6), WithPeriod(30*time.Second), WithAlgorithm(SHA1))
+}
+
+// PresetMicrosoft
+// Microsoft Authenticator: 6 digits, 30s period, SHA-1 (the defaults)
+func PresetMicrosoft() Option {
+	return preset(WithDigits(6), WithPeriod(30*time.Second), WithAlgorithm(SHA1))
+}
+
+// PresetRFC6238
+// The profile of the RFC 6238 test vectors: 8 digits, 30s period, SHA-1
+func PresetRFC6238() Option {
+	return preset(WithDigits(8), WithPeriod(30*time.Second), WithAlgorithm(SHA1))
+}
+
+// preset combines options into one
+func preset(opts ...Option) Option {
+	return func(c *config) error {
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Presets(t *testing.T) {
+	def, _ := New(rfc6238Secret)
+	for name, p := range map[string]Option{"Google": PresetGoogle(), "Microsoft": PresetMicrosoft()} {
+		o, err := New(rfc6238Secret, p)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !SameParams(o, def) || !o.isDefault() {
+			t.Fatalf("%s: parameters differ from the defaults", name)
+		}
+		for _, ts := range []int64{59, 1111111109, 2000000000} {
+			got, _ := o.TokenAt(time.Unix(ts, 0))
+			want, _ := def.TokenAt(time.Unix(ts, 0))
+			if got != want {
+				t.Fatalf("%s T=%d: got %q, want %q", name, ts, got, want)
+			}
+		}
+	}
+
+	o, err := New(rfc6238Secret, PresetRFC6238())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := o.TokenAt(time.Unix(1111111109, 0)); got != "07081804" {
+		t.Fatalf("got %q, want %q", got, "07081804")
+	}
+	// Later options override the preset
+	o, _ = New(rfc6238Secret, PresetMicrosoft(), WithDigits(8))
+	if got, _ := o.TokenAt(time.Unix(1111111109, 0)); got != "07081804" {
+		t.Fatalf("override: got %q, want %q", got, "07081804")
+	}
+}
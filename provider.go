@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SecretProvider
+// Return the current base32 secret, e.g. by reading it from a vault
+type SecretProvider func() (string, error)
+
+// ProviderTOTP
+// A generator that fetches its secret from a SecretProvider on every call,
+// for short-lived, externally rotated secrets. Nothing is cached, so every
+// code costs one provider call and one secret decode.
+type ProviderTOTP struct {
+	provider SecretProvider
+	opts     []Option
+}
+
+// NewWithProvider
+// Create a generator using provider for the secret and opts for everything
+// else. The options are checked here; the secret on every call.
+func NewWithProvider(provider SecretProvider, opts ...Option) (*ProviderTOTP, error) {
+	if provider == nil {
+		return nil, errors.New("secret provider must not be nil")
+	}
+	if _, err := newConfig(opts); err != nil {
+		return nil, err
+	}
+	return &ProviderTOTP{provider: provider, opts: opts}, nil
+}
+
+// Token
+// Generate the code for the current time with the current secret. The
+// current time comes from WithClock when it is among the options.
+func (p *ProviderTOTP) Token() (string, error) {
+	o, err := p.totp()
+	if err != nil {
+		return "", err
+	}
+	return o.TokenAt(o.now())
+}
+
+// TokenAt
+// Generate the code for time t with the current secret
+func (p *ProviderTOTP) TokenAt(t time.Time) (string, error) {
+	o, err := p.totp()
+	if err != nil {
+		return "", err
+	}
+	return o.TokenAt(t)
+}
+
+// totp fetches the current secret and builds a generator for it
+func (p *ProviderTOTP) totp() (*TOTP, error) {
+	secretKey, err := p.provider()
+	if err != nil {
+		return nil, fmt.Errorf("fetch secret: %w", err)
+	}
+	return New(secretKey, p.opts...)
+}
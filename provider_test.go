@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_ProviderTOTP(t *testing.T) {
+	pinTime(t, time.Unix(59, 0))
+	secret := rfc6238Secret
+	calls := 0
+	p, err := NewWithProvider(func() (string, error) {
+		calls++
+		return secret, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, err := p.Token()
+	if err != nil || first != "287082" {
+		t.Fatalf("got (%q, %v), want %q", first, err, "287082")
+	}
+
+	// Rotating the secret takes effect on the next call
+	secret = otherSecret
+	second, err := p.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := GetTokenAt(otherSecret, time.Unix(59, 0))
+	if second != want || second == first {
+		t.Fatalf("after rotation: got %q, want %q", second, want)
+	}
+	if calls != 2 {
+		t.Fatalf("provider called %d times, want 2", calls)
+	}
+}
+
+func Test_ProviderTOTP_Errors(t *testing.T) {
+	vaultDown := errors.New("vault unavailable")
+	p, _ := NewWithProvider(func() (string, error) { return "", vaultDown })
+	if _, err := p.Token(); !errors.Is(err, vaultDown) {
+		t.Fatalf("got %v, want the provider error", err)
+	}
+	if _, err := NewWithProvider(nil); err == nil {
+		t.Fatal("expected error for a nil provider")
+	}
+	if _, err := NewWithProvider(func() (string, error) { return rfc6238Secret, nil }, WithDigits(0)); err == nil {
+		t.Fatal("expected error for an invalid option")
+	}
+}
+
+func Test_ProviderTOTP_WithClock(t *testing.T) {
+	// The package clock is not pinned; Token must read the option's clock
+	p, err := NewWithProvider(func() (string, error) { return rfc6238Secret, nil },
+		WithClock(func() time.Time { return time.Unix(59, 0) }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := p.Token(); err != nil || got != "287082" {
+		t.Fatalf("got (%q, %v), want %q", got, err, "287082")
+	}
+}
@@ -0,0 +1,43 @@
+package totp
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrQRUnavailable is returned by WriteQR when no QR encoder is registered
+var ErrQRUnavailable = errors.New("no QR encoder registered")
+
+// QREncoder
+// Render content as a PNG QR code image to w
+type QREncoder func(w io.Writer, content string) error
+
+var (
+	qrMu      sync.RWMutex
+	qrEncoder QREncoder
+)
+
+// RegisterQREncoder
+// Install the encoder WriteQR uses. The package ships without one to stay
+// free of dependencies; a QR implementation registers itself here, usually
+// from an init function, as the qr subpackage does when imported. A nil
+// encoder removes the registration.
+func RegisterQREncoder(enc QREncoder) {
+	qrMu.Lock()
+	defer qrMu.Unlock()
+	qrEncoder = enc
+}
+
+// WriteQR
+// Render content, typically an otpauth URI, as a PNG QR code to w using the
+// registered encoder, or fail with ErrQRUnavailable
+func WriteQR(w io.Writer, content string) error {
+	qrMu.RLock()
+	enc := qrEncoder
+	qrMu.RUnlock()
+	if enc == nil {
+		return ErrQRUnavailable
+	}
+	return enc(w, content)
+}
@@ -0,0 +1,163 @@
+package qr
+
+// Error correction level M parameters per version, indexed by version
+// (ISO/IEC 18004 table 9); index 0 is unused
+var (
+	eccPerBlock = [maxVersion + 1]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26}
+	numBlocks   = [maxVersion + 1]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16}
+)
+
+// rawModules returns the number of modules available for codewords and
+// remainder bits, after all function patterns
+func rawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36 // two version information blocks
+		}
+	}
+	return n
+}
+
+// dataCodewords returns the number of data codewords at level M
+func dataCodewords(version int) int {
+	return rawModules(version)/8 - eccPerBlock[version]*numBlocks[version]
+}
+
+// countBits returns the width of the byte mode character count
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// dataCapacity returns the number of content bytes a version holds
+func dataCapacity(version int) int {
+	return (dataCodewords(version)*8 - 4 - countBits(version)) / 8
+}
+
+// bitBuffer accumulates bits most significant first
+type bitBuffer struct {
+	bytes []byte
+	n     int
+}
+
+// append adds the low width bits of v
+func (b *bitBuffer) append(v uint, width int) {
+	for i := width - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if v>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// encodeData builds the data codewords: byte mode header, content,
+// terminator and pad bytes
+func encodeData(version int, data []byte) []byte {
+	capacity := dataCodewords(version) * 8
+	var b bitBuffer
+	b.append(0b0100, 4) // byte mode
+	b.append(uint(len(data)), countBits(version))
+	for _, c := range data {
+		b.append(uint(c), 8)
+	}
+	b.append(0, min(4, capacity-b.n)) // terminator
+	b.append(0, (8-b.n%8)%8)
+	for pad := uint(0xEC); b.n < capacity; pad ^= 0xEC ^ 0x11 {
+		b.append(pad, 8)
+	}
+	return b.bytes
+}
+
+// addErrorCorrection splits the data into blocks, appends Reed-Solomon
+// error correction to each and interleaves the result
+func addErrorCorrection(version int, data []byte) []byte {
+	blocks := numBlocks[version]
+	ecc := eccPerBlock[version]
+	raw := rawModules(version) / 8
+	short := blocks - raw%blocks
+	shortLen := raw / blocks // data and ecc of a short block
+	divisor := rsDivisor(ecc)
+
+	var all [][]byte
+	for i, k := 0, 0; i < blocks; i++ {
+		n := shortLen - ecc
+		if i >= short {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		block = append(block, rsRemainder(block, divisor)...)
+		all = append(all, block)
+	}
+
+	// Short blocks have one data codeword fewer; skip that slot when
+	// interleaving so every block's ecc lines up
+	result := make([]byte, 0, raw)
+	for i := 0; i <= shortLen; i++ {
+		for j, block := range all {
+			if i == shortLen-ecc && j < short {
+				continue
+			}
+			k := i
+			if j < short && i > shortLen-ecc {
+				k--
+			}
+			if k < len(block) {
+				result = append(result, block[k])
+			}
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z <<= 1
+		z ^= carry * 0x1D
+		z ^= (y >> i & 1) * x
+	}
+	return z
+}
+
+// rsDivisor returns the generator polynomial of the given degree, without
+// its leading 1, highest coefficient first
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the Reed-Solomon error correction codewords of data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
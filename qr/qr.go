@@ -0,0 +1,112 @@
+// Package qr renders otpauth URIs as QR codes. It is kept out of the core
+// package so that callers who only need codes and URIs do not carry it;
+// importing it for its side effect makes totp.WriteQR and totp.Enroll
+// produce PNG images:
+//
+//	import _ "github.com/yousysadmin/totp/qr"
+//
+// WriteQRTerminal draws the same symbols with Unicode blocks for CLIs.
+//
+// The encoder supports byte mode at error correction level M for versions
+// 1 to 20, which holds up to 666 bytes, far more than any otpauth URI.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/yousysadmin/totp"
+)
+
+func init() {
+	totp.RegisterQREncoder(WritePNG)
+}
+
+const (
+	// maxVersion is the largest symbol version Encode produces
+	maxVersion = 20
+	// quietZone is the light border around a rendered symbol, in modules
+	quietZone = 4
+	// moduleSize is the side of one module in a PNG, in pixels
+	moduleSize = 8
+)
+
+// ErrTooLong is returned when content does not fit the largest symbol
+var ErrTooLong = errors.New("content too long for a QR code")
+
+// Code
+// A QR code symbol: a square of dark and light modules
+type Code struct {
+	// Size is the number of modules per side
+	Size    int
+	modules []bool
+}
+
+// Dark
+// Report whether the module at column x, row y is dark. Coordinates
+// outside the symbol are light, so callers can draw a quiet zone freely.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+// Encode
+// Build the smallest symbol holding content in byte mode at error
+// correction level M
+func Encode(content string) (*Code, error) {
+	data := []byte(content)
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if len(data) <= dataCapacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrTooLong, len(data), dataCapacity(maxVersion))
+	}
+
+	s := newSymbol(version)
+	s.drawFunctionPatterns()
+	s.drawCodewords(addErrorCorrection(version, encodeData(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		s.applyMask(mask)
+		s.drawFormatBits(mask)
+		if p := s.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		s.applyMask(mask) // masks are XOR, so applying again undoes it
+	}
+	s.applyMask(best)
+	s.drawFormatBits(best)
+	return &Code{Size: s.size, modules: s.modules}, nil
+}
+
+// WritePNG
+// Render content as a black-on-white PNG QR code with a quiet zone, eight
+// pixels per module. It is the encoder this package registers with
+// totp.RegisterQREncoder.
+func WritePNG(w io.Writer, content string) error {
+	c, err := Encode(content)
+	if err != nil {
+		return err
+	}
+	side := (c.Size + 2*quietZone) * moduleSize
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := range side {
+		for x := range side {
+			if c.Dark(x/moduleSize-quietZone, y/moduleSize-quietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,253 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/yousysadmin/totp"
+)
+
+func Test_rsRemainder_Vector(t *testing.T) {
+	// "HELLO WORLD" at 1-M in alphanumeric mode, from the ISO/IEC 18004
+	// worked example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func Test_formatBits_LevelM(t *testing.T) {
+	want := []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	}
+	for mask, w := range want {
+		if got := formatBits(mask); got != w {
+			t.Fatalf("mask %d: got %015b, want %015b", mask, got, w)
+		}
+	}
+}
+
+func Test_versionBits_Version7(t *testing.T) {
+	if got, want := versionBits(7), 0b000111110010010100; got != want {
+		t.Fatalf("got %018b, want %018b", got, want)
+	}
+}
+
+func Test_dataCapacity_Table(t *testing.T) {
+	for version, want := range map[int]int{1: 14, 2: 26, 7: 122, 10: 213, 20: 666} {
+		if got := dataCapacity(version); got != want {
+			t.Fatalf("version %d: got %d, want %d", version, got, want)
+		}
+	}
+}
+
+func Test_rawModules_MatchesSymbol(t *testing.T) {
+	for version := 1; version <= maxVersion; version++ {
+		s := newSymbol(version)
+		s.drawFunctionPatterns()
+		free := 0
+		for _, f := range s.function {
+			if !f {
+				free++
+			}
+		}
+		if free != rawModules(version) {
+			t.Fatalf("version %d: got %d free modules, want %d", version, free, rawModules(version))
+		}
+	}
+}
+
+func Test_Encode_RoundTrip(t *testing.T) {
+	uris := []string{
+		"",
+		"otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+		"otpauth://totp/ACME%20Co:john.doe@email.com?algorithm=SHA512&digits=8&issuer=ACME%20Co&period=60&secret=" + strings.Repeat("A", 103),
+		strings.Repeat("x", 666),
+	}
+	for _, content := range uris {
+		c, err := Encode(content)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(content), err)
+		}
+		if got := decode(t, c); got != content {
+			t.Fatalf("got %q, want %q", got, content)
+		}
+	}
+}
+
+func Test_Encode_TooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("x", 667)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("got %v, want ErrTooLong", err)
+	}
+}
+
+func Test_WritePNG_Image(t *testing.T) {
+	content := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP"
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, content); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := Encode(content)
+	side := (c.Size + 2*quietZone) * moduleSize
+	if b := img.Bounds(); b.Dx() != side || b.Dy() != side {
+		t.Fatalf("got %v, want %dx%d", b, side, side)
+	}
+	dark := func(x, y int) bool {
+		r, _, _, _ := img.At(x, y).RGBA()
+		return r == 0
+	}
+	if dark(0, 0) {
+		t.Fatal("quiet zone is dark")
+	}
+	// Top-left module of the finder pattern
+	if p := quietZone * moduleSize; !dark(p, p) {
+		t.Fatal("finder corner is light")
+	}
+}
+
+func Test_init_RegistersEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := totp.WriteQR(&buf, "otpauth://totp/a?secret=JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatalf("WriteQR: %v", err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	_, img, _, err := totp.Enroll("Example", "alice")
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(img)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// decode reads a symbol back: it recovers the mask from the format bits,
+// unmasks, reads the zigzag, de-interleaves the blocks and parses the byte
+// mode segment
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	version := (c.Size - 17) / 4
+
+	var format int
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | bit(c.Dark(14-i, 8))
+	}
+	format = format<<1 | bit(c.Dark(7, 8))
+	format = format<<1 | bit(c.Dark(8, 8))
+	format = format<<1 | bit(c.Dark(8, 7))
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | bit(c.Dark(8, i))
+	}
+	mask := -1
+	for m := range 8 {
+		if formatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format bits %015b match no level M mask", format)
+	}
+
+	layout := newSymbol(version)
+	layout.drawFunctionPatterns()
+	var codewords []byte
+	var cur byte
+	n := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range c.Size {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = c.Size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if layout.function[y*c.Size+x] {
+					continue
+				}
+				cur = cur<<1 | byte(bit(c.Dark(x, y) != masks[mask](x, y)))
+				if n++; n%8 == 0 {
+					codewords = append(codewords, cur)
+					cur = 0
+				}
+			}
+		}
+	}
+
+	blocks := numBlocks[version]
+	ecc := eccPerBlock[version]
+	raw := rawModules(version) / 8
+	short := blocks - raw%blocks
+	dataLen := func(j int) int {
+		if j < short {
+			return raw/blocks - ecc
+		}
+		return raw/blocks - ecc + 1
+	}
+	per := make([][]byte, blocks)
+	k := 0
+	for i := 0; i < raw/blocks-ecc+1; i++ {
+		for j := range blocks {
+			if i < dataLen(j) {
+				per[j] = append(per[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for j := range blocks {
+		want := make([]byte, ecc)
+		for i := range want {
+			want[i] = codewords[k+i*blocks+j]
+		}
+		if got := rsRemainder(per[j], rsDivisor(ecc)); !bytes.Equal(got, want) {
+			t.Fatalf("block %d: got ecc %v, want %v", j, got, want)
+		}
+		data = append(data, per[j]...)
+	}
+
+	if mode := data[0] >> 4; mode != 0b0100 {
+		t.Fatalf("got mode %04b, want byte mode", mode)
+	}
+	r := bitReader{data: data, n: 4}
+	length := r.read(countBits(version))
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(r.read(8))
+	}
+	return string(out)
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type bitReader struct {
+	data []byte
+	n    int
+}
+
+func (r *bitReader) read(width int) int {
+	v := 0
+	for range width {
+		v = v<<1 | int(r.data[r.n/8]>>(7-r.n%8)&1)
+		r.n++
+	}
+	return v
+}
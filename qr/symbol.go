@@ -0,0 +1,293 @@
+package qr
+
+// symbol is a module matrix under construction. function marks the
+// modules of function patterns, which codewords and masks skip.
+type symbol struct {
+	version  int
+	size     int
+	modules  []bool
+	function []bool
+}
+
+// newSymbol returns an all-light symbol of the given version
+func newSymbol(version int) *symbol {
+	size := 4*version + 17
+	return &symbol{
+		version:  version,
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+}
+
+// set colors the module at column x, row y
+func (s *symbol) set(x, y int, dark bool) {
+	s.modules[y*s.size+x] = dark
+}
+
+// setFunction colors a function pattern module and reserves it
+func (s *symbol) setFunction(x, y int, dark bool) {
+	s.set(x, y, dark)
+	s.function[y*s.size+x] = true
+}
+
+// dark reports the color of the module at column x, row y
+func (s *symbol) dark(x, y int) bool {
+	return s.modules[y*s.size+x]
+}
+
+// drawFunctionPatterns draws everything but the codewords. The format
+// areas are reserved with a dummy mask and overwritten by drawFormatBits.
+func (s *symbol) drawFunctionPatterns() {
+	for i := range s.size {
+		s.setFunction(6, i, i%2 == 0)
+		s.setFunction(i, 6, i%2 == 0)
+	}
+	s.drawFinder(3, 3)
+	s.drawFinder(s.size-4, 3)
+	s.drawFinder(3, s.size-4)
+
+	positions := alignmentPositions(s.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners taken by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			s.drawAlignment(x, y)
+		}
+	}
+
+	s.drawFormatBits(0)
+	s.drawVersion()
+}
+
+// drawFinder draws a finder pattern and its separator centered on (x, y)
+func (s *symbol) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= s.size || yy >= s.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			s.setFunction(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern centered on (x, y)
+func (s *symbol) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			s.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPositions returns the row and column centers of the alignment
+// patterns, ascending
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, 4*version+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// formatBits returns the 15 format information bits for level M and mask
+func formatBits(mask int) int {
+	data := 0b00<<3 | mask // level M
+	rem := data
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormatBits draws both copies of the format information for mask
+func (s *symbol) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	// Around the top-left finder
+	for i := 0; i <= 5; i++ {
+		s.setFunction(8, i, bit(i))
+	}
+	s.setFunction(8, 7, bit(6))
+	s.setFunction(8, 8, bit(7))
+	s.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		s.setFunction(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finders
+	for i := range 8 {
+		s.setFunction(s.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		s.setFunction(8, s.size-15+i, bit(i))
+	}
+	s.setFunction(8, s.size-8, true) // the dark module
+}
+
+// versionBits returns the 18 version information bits
+func versionBits(version int) int {
+	rem := version
+	for range 12 {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+// drawVersion draws both copies of the version information, present from
+// version 7 on
+func (s *symbol) drawVersion() {
+	if s.version < 7 {
+		return
+	}
+	bits := versionBits(s.version)
+	for i := range 18 {
+		dark := bits>>i&1 == 1
+		a, b := s.size-11+i%3, i/3
+		s.setFunction(a, b, dark)
+		s.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the two-column zigzag from the
+// bottom-right corner, skipping function modules. Modules left over are
+// the remainder bits and stay light.
+func (s *symbol) drawCodewords(data []byte) {
+	i := 0
+	for right := s.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := range s.size {
+			y := vert
+			if upward {
+				y = s.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if s.function[y*s.size+x] || i >= len(data)*8 {
+					continue
+				}
+				s.set(x, y, data[i/8]>>(7-i%8)&1 == 1)
+				i++
+			}
+		}
+	}
+}
+
+// masks are the eight data mask conditions; a module at column x, row y is
+// inverted when the condition holds
+var masks = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+// applyMask inverts the non-function modules selected by mask
+func (s *symbol) applyMask(mask int) {
+	for y := range s.size {
+		for x := range s.size {
+			if !s.function[y*s.size+x] && masks[mask](x, y) {
+				s.modules[y*s.size+x] = !s.modules[y*s.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four ISO/IEC 18004 rules; the mask
+// with the lowest score is the easiest to scan
+func (s *symbol) penalty() int {
+	p := 0
+	darkCount := 0
+	for a := range s.size {
+		p += s.linePenalty(func(b int) bool { return s.dark(b, a) })
+		p += s.linePenalty(func(b int) bool { return s.dark(a, b) })
+		for b := range s.size {
+			if s.dark(b, a) {
+				darkCount++
+			}
+		}
+	}
+	// Rule 2: 2x2 blocks of one color
+	for y := 0; y < s.size-1; y++ {
+		for x := 0; x < s.size-1; x++ {
+			c := s.dark(x, y)
+			if c == s.dark(x+1, y) && c == s.dark(x, y+1) && c == s.dark(x+1, y+1) {
+				p += 3
+			}
+		}
+	}
+	// Rule 4: deviation of the dark share from 50%, in steps of 5%
+	total := s.size * s.size
+	deviation := abs(darkCount*20-total*10) / total
+	return p + deviation*10
+}
+
+// linePenalty applies rules 1 and 3 to one row or column
+func (s *symbol) linePenalty(dark func(int) bool) int {
+	p := 0
+	// Rule 1: runs of five or more modules of one color
+	run := 1
+	for i := 1; i <= s.size; i++ {
+		if i < s.size && dark(i) == dark(i-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			p += 3 + run - 5
+		}
+		run = 1
+	}
+	// Rule 3: finder-like 1:1:3:1:1 patterns with four light modules on
+	// either side; positions outside the symbol count as light
+	at := func(i int) bool { return i >= 0 && i < s.size && dark(i) }
+	finder := [7]bool{true, false, true, true, true, false, true}
+	for i := -4; i < s.size+4-7; i++ {
+		match := true
+		for k, want := range finder {
+			if at(i+k) != want {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		before, after := true, true
+		for k := 1; k <= 4; k++ {
+			before = before && !at(i-k)
+			after = after && !at(i+6+k)
+		}
+		if before || after {
+			p += 40
+		}
+	}
+	return p
+}
+
+// abs returns the absolute value of x
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
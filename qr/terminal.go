@@ -0,0 +1,54 @@
+package qr
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/yousysadmin/totp"
+)
+
+// WriteQRTerminal
+// Build the otpauth URI for the secret, as totp.BuildURI does, and draw it
+// as a QR code with Unicode half blocks, for enrollment from a CLI where
+// no image can be shown
+func WriteQRTerminal(w io.Writer, issuer, account, secretKey string, opts ...totp.URIOption) error {
+	uri, err := totp.BuildURI(issuer, account, secretKey, opts...)
+	if err != nil {
+		return err
+	}
+	return WriteTerminal(w, uri)
+}
+
+// WriteTerminal
+// Draw content as a QR code with Unicode half blocks, two module rows per
+// line. Light modules are drawn as blocks and dark ones as blanks, which
+// scans on the usual light-on-dark terminal; the quiet zone is included.
+func WriteTerminal(w io.Writer, content string) error {
+	c, err := Encode(content)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for y := -quietZone; y < c.Size+quietZone; y += 2 {
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			top := !c.Dark(x, y)
+			bottom := !c.Dark(x, y+1) && y+1 < c.Size+quietZone
+			bw.WriteString(halfBlock(top, bottom))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// halfBlock returns the character lighting the top and bottom halves of a cell
+func halfBlock(top, bottom bool) string {
+	switch {
+	case top && bottom:
+		return "█"
+	case top:
+		return "▀"
+	case bottom:
+		return "▄"
+	}
+	return " "
+}
@@ -0,0 +1,43 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_WriteQRTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteQRTerminal(&buf, "Example", "alice@example.com", "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("no output")
+	}
+
+	// Reading the blocks back gives the modules of the encoded URI
+	c, _ := Encode("otpauth://totp/Example:alice@example.com?issuer=Example&secret=JBSWY3DPEHPK3PXP")
+	side := c.Size + 2*quietZone
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != (side+1)/2 {
+		t.Fatalf("got %d lines, want %d", len(lines), (side+1)/2)
+	}
+	for i, line := range lines {
+		cells := []rune(line)
+		if len(cells) != side {
+			t.Fatalf("line %d: got %d cells, want %d", i, len(cells), side)
+		}
+		for j, r := range cells {
+			x, y := j-quietZone, 2*i-quietZone
+			top := r == '█' || r == '▀'
+			bottom := r == '█' || r == '▄'
+			if top != !c.Dark(x, y) || (y+1 < c.Size+quietZone && bottom != !c.Dark(x, y+1)) {
+				t.Fatalf("cell (%d, %d) %q does not match the symbol", j, i, r)
+			}
+		}
+	}
+
+	if err := WriteQRTerminal(&buf, "Example", "", "JBSWY3DPEHPK3PXP"); err == nil {
+		t.Fatal("expected error for an empty account")
+	}
+}
@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultReplayEntries bounds the in-memory store a Verifier creates; with
+// one secret per Verifier at most 2*skew+1 entries are live at a time
+const defaultReplayEntries = 1024
+
+// ErrTokenReused is returned by Verifier when a valid code was already
+// accepted
+var ErrTokenReused = errors.New("token already used")
+
+// ReplayStore
+// Remember accepted codes so a Verifier accepts each one once, e.g. in
+// Redis with SET NX and an expiry. Keys never contain the secret.
+// Implementations must be safe for concurrent use.
+type ReplayStore interface {
+	// Claim records key until expiresAt and reports whether it was
+	// unclaimed at now, the Verifier's validation time. It must be atomic:
+	// of two concurrent claims on the same key exactly one succeeds. The
+	// entry may be dropped after expiresAt; a store with relative expiry
+	// should keep it for expiresAt.Sub(now), not read its own clock, so
+	// that explicit validation times and WithClock stay consistent.
+	Claim(key string, now, expiresAt time.Time) (bool, error)
+}
+
+// MemoryReplayStore
+// A bounded in-memory ReplayStore, the Verifier default. Expired entries
+// are evicted when the store fills up; if it is still full, the entry
+// closest to expiry is dropped, so size it for every code that can be
+// live at once.
+type MemoryReplayStore struct {
+	mu         sync.Mutex
+	entries    map[string]time.Time
+	maxEntries int
+}
+
+// NewMemoryReplayStore
+// Create a store holding at most maxEntries codes
+func NewMemoryReplayStore(maxEntries int) (*MemoryReplayStore, error) {
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("max entries must be positive, got %d", maxEntries)
+	}
+	return &MemoryReplayStore{entries: make(map[string]time.Time), maxEntries: maxEntries}, nil
+}
+
+// Claim implements ReplayStore
+func (s *MemoryReplayStore) Claim(key string, now, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if exp, ok := s.entries[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+	if len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+	s.entries[key] = expiresAt
+	return true, nil
+}
+
+// evict drops expired entries, or the one closest to expiry if none has
+func (s *MemoryReplayStore) evict(now time.Time) {
+	var oldest string
+	var oldestExp time.Time
+	for k, exp := range s.entries {
+		if !now.Before(exp) {
+			delete(s.entries, k)
+			continue
+		}
+		if oldest == "" || exp.Before(oldestExp) {
+			oldest, oldestExp = k, exp
+		}
+	}
+	if len(s.entries) >= s.maxEntries {
+		delete(s.entries, oldest)
+	}
+}
+
+// fingerprint identifies a secret in store keys without revealing it
+func fingerprint(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return "totp:" + hex.EncodeToString(sum[:])
+}
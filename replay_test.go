@@ -0,0 +1,146 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Verifier_RejectsReplay(t *testing.T) {
+	// The package clock is deliberately not pinned: the store must judge
+	// expiry by the validation time, not by wall-clock now
+	at := time.Unix(1111111109, 0)
+	v, err := NewVerifier(rfc6238Secret, WithSkew(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ok, err := v.AcceptAt("081804", at); err != nil || !ok {
+		t.Fatalf("first use: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := v.AcceptAt("081804", at.Add(-5*time.Second)); !errors.Is(err, ErrTokenReused) || ok {
+		t.Fatalf("second use in the same window: got (%v, %v), want ErrTokenReused", ok, err)
+	}
+	// The next window's code is a different entry
+	if ok, err := v.AcceptAt("050471", at); err != nil || !ok {
+		t.Fatalf("next window: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_Verifier_RejectsReplay_WithClock(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	v, err := NewVerifier(rfc6238Secret, WithTOTPOptions(WithClock(func() time.Time { return at })))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, err := v.Accept("081804"); err != nil || !ok {
+		t.Fatalf("first use: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := v.Accept("081804"); !errors.Is(err, ErrTokenReused) || ok {
+		t.Fatalf("second use: got (%v, %v), want ErrTokenReused", ok, err)
+	}
+}
+
+func Test_MemoryReplayStore(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s, err := NewMemoryReplayStore(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ok, _ := s.Claim("a", now, now.Add(time.Minute)); !ok {
+		t.Fatal("first claim of a failed")
+	}
+	if ok, _ := s.Claim("a", now, now.Add(time.Minute)); ok {
+		t.Fatal("second claim of a succeeded")
+	}
+
+	// Filling the store evicts expired entries first, then the soonest
+	s.Claim("b", now, now.Add(-time.Second))
+	s.Claim("c", now, now.Add(2*time.Minute))
+	if len(s.entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(s.entries))
+	}
+	if _, ok := s.entries["b"]; ok {
+		t.Fatal("expired entry b was kept")
+	}
+	s.Claim("d", now, now.Add(3*time.Minute))
+	if _, ok := s.entries["a"]; ok || len(s.entries) != 2 {
+		t.Fatalf("soonest entry a was kept: %v", s.entries)
+	}
+
+	// An expired entry can be claimed again
+	if ok, _ := s.Claim("c", now.Add(5*time.Minute), now.Add(6*time.Minute)); !ok {
+		t.Fatal("claim of expired c failed")
+	}
+
+	if _, err := NewMemoryReplayStore(0); err == nil {
+		t.Fatal("expected error for zero capacity")
+	}
+}
+
+func Test_Verifier_ReplayWindow(t *testing.T) {
+	// One-digit codes repeat often: find two adjacent windows with the
+	// same digit, and a later one past the replay window
+	o, _ := New(rfc6238Secret, WithDigits(1))
+	c := uint64(37037036)
+	for o.code(c) != o.code(c+1) {
+		c++
+	}
+	code := o.code(c)
+	later := c + 4
+	for o.code(later) != code {
+		later++
+	}
+	at := func(counter uint64) time.Time { return time.Unix(int64(counter)*30+10, 0) }
+
+	// The package clock is not pinned; verifiers read a WithClock clock
+	var now time.Time
+	newVerifier := func(opts ...VerifierOption) *Verifier {
+		clock := WithClock(func() time.Time { return now })
+		v, err := NewVerifier(rfc6238Secret, append(opts, WithSkew(1), WithTOTPOptions(WithDigits(1), clock))...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return v
+	}
+
+	// Per-window protection accepts the digits again in the next window
+	v := newVerifier()
+	now = at(c)
+	if ok, err := v.Accept(code); err != nil || !ok {
+		t.Fatalf("first use: got (%v, %v), want (true, nil)", ok, err)
+	}
+	now = at(c + 1)
+	if ok, err := v.Accept(code); err != nil || !ok {
+		t.Fatalf("next window without a replay window: got (%v, %v), want (true, nil)", ok, err)
+	}
+
+	// The sliding window rejects them until it has passed
+	v = newVerifier(WithReplayWindow(90 * time.Second))
+	now = at(c)
+	if ok, err := v.Accept(code); err != nil || !ok {
+		t.Fatalf("first use: got (%v, %v), want (true, nil)", ok, err)
+	}
+	now = at(c + 1)
+	if ok, err := v.Accept(code); !errors.Is(err, ErrTokenReused) || ok {
+		t.Fatalf("next window: got (%v, %v), want ErrTokenReused", ok, err)
+	}
+	now = at(later)
+	if ok, err := v.Accept(code); err != nil || !ok {
+		t.Fatalf("after the replay window: got (%v, %v), want (true, nil)", ok, err)
+	}
+
+	// AcceptAt uses its explicit time the same way
+	v = newVerifier(WithReplayWindow(90 * time.Second))
+	if ok, err := v.AcceptAt(code, at(c)); err != nil || !ok {
+		t.Fatalf("AcceptAt first use: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := v.AcceptAt(code, at(c+1)); !errors.Is(err, ErrTokenReused) || ok {
+		t.Fatalf("AcceptAt next window: got (%v, %v), want ErrTokenReused", ok, err)
+	}
+
+	if _, err := NewVerifier(rfc6238Secret, WithReplayWindow(0)); err == nil {
+		t.Fatal("expected error for a zero replay window")
+	}
+}
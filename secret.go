@@ -0,0 +1,125 @@
+package totp
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// minDistinctSecretBytes is the fewest distinct byte values a secret must
+// contain to pass CheckSecret
+const minDistinctSecretBytes = 3
+
+// ErrWeakSecret is returned for secrets that decode to trivially weak keys
+var ErrWeakSecret = errors.New("secret is trivially weak")
+
+// CheckSecret
+// Reject a user-supplied secret that decodes to a pathological key: empty,
+// a single repeated byte (e.g. all zeros) or only two distinct byte values.
+// Randomly generated secrets never trip this; it exists for enrollment paths
+// accepting secrets from users.
+func CheckSecret(secretKey string) error {
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return err
+	}
+	return checkSecretBytes(secretBytes)
+}
+
+// checkSecretBytes applies the CheckSecret rules to a decoded secret
+func checkSecretBytes(secretBytes []byte) error {
+	if len(secretBytes) == 0 {
+		return fmt.Errorf("%w: empty", ErrWeakSecret)
+	}
+	seen := make(map[byte]struct{}, minDistinctSecretBytes)
+	for _, b := range secretBytes {
+		seen[b] = struct{}{}
+		if len(seen) >= minDistinctSecretBytes {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: only %d distinct byte values", ErrWeakSecret, len(seen))
+}
+
+// EncodeSecret
+// Encode a raw secret, such as one from a KDF, as unpadded uppercase base32.
+// This is the canonical form the decoder expects, so the result round-trips
+// through New and GetToken.
+func EncodeSecret(secret []byte) string {
+	return secretEncoding.EncodeToString(secret)
+}
+
+// minMasterBytes is the shortest master key DeriveSecret accepts
+const minMasterBytes = 16
+
+// DeriveSecret
+// Derive a length-byte secret from a master key with HKDF-SHA256 (RFC 5869)
+// and an account-specific info string such as "totp:alice@example.com", for
+// deterministic multi-account provisioning. The same inputs always give the
+// same secret; pass it to EncodeSecret for the base32 form. The master key
+// must be at least 16 bytes and should be uniformly random.
+func DeriveSecret(master []byte, info string, length int) ([]byte, error) {
+	if len(master) < minMasterBytes {
+		return nil, fmt.Errorf("master key is %d bytes, want at least %d", len(master), minMasterBytes)
+	}
+	if length < 1 {
+		return nil, fmt.Errorf("invalid secret length %d", length)
+	}
+	return hkdf.Key(sha256.New, master, nil, info, length)
+}
+
+// RedactSecret
+// Mask a secret for display in logs, keeping only its first four and last
+// three characters ("GEZD…OJQ"). Secrets too short to give away that much are
+// fully masked. The result is for display only and cannot be used to
+// generate codes. No function in this package logs secrets.
+func RedactSecret(secretKey string) string {
+	s := normalizeSecret(secretKey)
+	if len(s) < 16 {
+		return "…"
+	}
+	return s[:4] + "…" + s[len(s)-3:]
+}
+
+// Encoding
+// The textual encoding of a secret, as guessed by DetectEncoding
+type Encoding int
+
+const (
+	// EncodingAmbiguous means the input is valid in more than one encoding
+	EncodingAmbiguous Encoding = iota
+	// EncodingBase32 is RFC 4648 base32, the otpauth format
+	EncodingBase32
+	// EncodingHex is hexadecimal, as some servers export raw keys
+	EncodingHex
+)
+
+// ErrUnknownEncoding is returned when a secret is neither base32 nor hex
+var ErrUnknownEncoding = errors.New("secret is neither base32 nor hex")
+
+// DetectEncoding
+// Guess whether a pasted secret is base32 or hex. Whitespace is ignored and
+// base32 is accepted in either case with optional padding. The guess is
+// conservative: input that decodes both ways (only the characters 2-7 and
+// A-F, with a length valid for both) is EncodingAmbiguous, and the caller
+// should ask rather than pick one.
+func DetectEncoding(s string) (Encoding, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if s == "" {
+		return EncodingAmbiguous, fmt.Errorf("%w: empty", ErrUnknownEncoding)
+	}
+	_, base32Err := decodeSecret(s)
+	_, hexErr := hex.DecodeString(s)
+	switch {
+	case base32Err == nil && hexErr == nil:
+		return EncodingAmbiguous, nil
+	case base32Err == nil:
+		return EncodingBase32, nil
+	case hexErr == nil:
+		return EncodingHex, nil
+	}
+	return EncodingAmbiguous, ErrUnknownEncoding
+}
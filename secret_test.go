@@ -0,0 +1,137 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_CheckSecret(t *testing.T) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	weak := map[string][]byte{
+		"all zero":     make([]byte, 20),
+		"repeated":     []byte("AAAAAAAAAAAAAAAAAAAA"),
+		"two values":   []byte("ABABABABABABABABABAB"),
+		"empty secret": {},
+	}
+	for name, key := range weak {
+		if err := CheckSecret(enc.EncodeToString(key)); !errors.Is(err, ErrWeakSecret) {
+			t.Fatalf("%s: got %v, want ErrWeakSecret", name, err)
+		}
+	}
+
+	if err := CheckSecret(rfc6238Secret); err != nil {
+		t.Fatalf("RFC secret: unexpected error: %v", err)
+	}
+}
+
+func Test_WithWeakSecretCheck(t *testing.T) {
+	zero := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(make([]byte, 20))
+	if _, err := New(zero); err != nil {
+		t.Fatalf("default: unexpected error: %v", err)
+	}
+	if _, err := New(zero, WithWeakSecretCheck()); !errors.Is(err, ErrWeakSecret) {
+		t.Fatalf("opt-in: got %v, want ErrWeakSecret", err)
+	}
+}
+
+func Test_RedactSecret(t *testing.T) {
+	if got := RedactSecret(rfc6238Secret); got != "GEZD…OJQ" {
+		t.Fatalf("got %q, want %q", got, "GEZD…OJQ")
+	}
+	for _, secret := range []string{
+		rfc6238Secret,
+		"JBSWY3DPEHPK3PXP",
+		"JBSWY3DP",
+		"AB",
+		"",
+	} {
+		got := RedactSecret(secret)
+		if secret != "" && strings.Contains(got, secret) {
+			t.Fatalf("%q: redacted form %q contains the secret", secret, got)
+		}
+		if len(secret) > 0 && got == secret {
+			t.Fatalf("%q: returned unchanged", secret)
+		}
+	}
+}
+
+func Test_EncodeSecret(t *testing.T) {
+	secretBytes, err := decodeSecret(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := EncodeSecret(secretBytes); got != rfc6238Secret {
+		t.Fatalf("got %q, want %q", got, rfc6238Secret)
+	}
+	// 4 bytes do not fill a base32 block, so padding would normally follow
+	short := []byte{1, 2, 3, 4}
+	back, err := decodeSecret(EncodeSecret(short))
+	if err != nil || string(back) != string(short) {
+		t.Fatalf("round-trip: got %v, %v", back, err)
+	}
+}
+
+func Test_DetectEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Encoding
+	}{
+		{"3132333435363738393031323334353637383930", EncodingHex}, // "12345678901234567890"
+		{"deadbeef00", EncodingHex},
+		{rfc6238Secret, EncodingBase32},
+		{"jbsw y3dp ehpk 3pxp", EncodingBase32},
+		{"JBSWY3DPEE======", EncodingBase32},
+		{"ABCDEF23", EncodingAmbiguous},
+		{"2222222222222222", EncodingAmbiguous},
+	} {
+		got, err := DetectEncoding(tc.in)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: got %d, want %d", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "   ", "not a secret!", "xyz1"} {
+		if _, err := DetectEncoding(in); !errors.Is(err, ErrUnknownEncoding) {
+			t.Fatalf("%q: got %v, want ErrUnknownEncoding", in, err)
+		}
+	}
+}
+
+func Test_DeriveSecret(t *testing.T) {
+	master := make([]byte, 32)
+	for i := range master {
+		master[i] = byte(i)
+	}
+	alice, err := DeriveSecret(master, "totp:alice", 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// HKDF-SHA256 with an empty salt, computed independently with Python's hmac
+	if got, want := hex.EncodeToString(alice), "afa70d23add4f88e74bf312faecb91d641034e08"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	again, _ := DeriveSecret(master, "totp:alice", 20)
+	if !bytes.Equal(alice, again) {
+		t.Fatal("derivation is not deterministic")
+	}
+	bob, _ := DeriveSecret(master, "totp:bob", 20)
+	if bytes.Equal(alice, bob) {
+		t.Fatal("different info gave the same secret")
+	}
+	if _, err := GetToken(EncodeSecret(alice)); err != nil {
+		t.Fatalf("derived secret does not generate codes: %v", err)
+	}
+
+	if _, err := DeriveSecret(master[:8], "totp:alice", 20); err == nil {
+		t.Fatal("expected error for a short master key")
+	}
+	if _, err := DeriveSecret(master, "totp:alice", 0); err == nil {
+		t.Fatal("expected error for zero length")
+	}
+}
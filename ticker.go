@@ -0,0 +1,129 @@
+package totp
+
+import (
+	"sync"
+	"time"
+)
+
+// Tick
+// A code together with the start of its window, as emitted by a Ticker or
+// listed by GenerateTable and DayTokens
+type Tick struct {
+	WindowStart time.Time
+	Code        string
+}
+
+// Ticker
+// Deliver the current code on C immediately and then at every window
+// boundary. Each tick is scheduled with a timer armed for the next computed
+// boundary, not a fixed interval, so it stays phase-locked to wall-clock
+// windows after the process sleeps or the clock is adjusted. If the receiver
+// falls behind, stale ticks are replaced by the latest one.
+type Ticker struct {
+	C <-chan Tick
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// clock abstracts time for the Ticker so tests can drive it
+type clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) timer
+}
+
+// timer is the subset of *time.Timer the Ticker uses
+type timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// systemClock is the clock backed by a TOTP's current time and time.Timer
+type systemClock struct{ now func() time.Time }
+
+func (c systemClock) Now() time.Time { return c.now() }
+
+func (systemClock) NewTimer(d time.Duration) timer { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct{ t *time.Timer }
+
+func (s systemTimer) C() <-chan time.Time        { return s.t.C }
+func (s systemTimer) Reset(d time.Duration) bool { return s.t.Reset(d) }
+func (s systemTimer) Stop() bool                 { return s.t.Stop() }
+
+// NewTicker
+// Start a Ticker for the secret. Call Stop to release it. A WithClock clock
+// decides which window is current; the waits between ticks are still timed
+// by the system clock.
+func NewTicker(secretKey string, opts ...Option) (*Ticker, error) {
+	o, err := New(secretKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newTicker(o, systemClock{now: o.now}), nil
+}
+
+// newTicker starts a Ticker driven by clk
+func newTicker(o *TOTP, clk clock) *Ticker {
+	out := make(chan Tick, 1)
+	t := &Ticker{C: out, stop: make(chan struct{}), done: make(chan struct{})}
+	go t.run(o, clk, out)
+	return t
+}
+
+// Stop
+// Stop the Ticker. No more ticks are sent after it returns; C is not closed.
+func (t *Ticker) Stop() {
+	t.stopOnce.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+// run emits ticks until Stop is called. On every wakeup the window is
+// recomputed from the clock, so early fires, sleeps and clock steps in
+// either direction all resolve to the window that is current right now.
+func (t *Ticker) run(o *TOTP, clk clock, out chan Tick) {
+	defer close(t.done)
+	period := o.step()
+	tm := clk.NewTimer(time.Hour)
+	defer tm.Stop()
+
+	emitted := false
+	var last int64
+	for {
+		now := clk.Now().Unix()
+		start := now - mod(now-o.epoch, period)
+		if !emitted || start != last {
+			// Pre-epoch windows have no code; wait for the next boundary
+			if counter, err := o.counterAt(start); err == nil {
+				t.send(out, Tick{
+					WindowStart: time.Unix(start, 0).UTC(),
+					Code:        o.code(counter),
+				})
+			}
+			emitted, last = true, start
+		}
+
+		tm.Reset(time.Unix(start+period, 0).Sub(clk.Now()))
+		select {
+		case <-t.stop:
+			return
+		case <-tm.C():
+		}
+	}
+}
+
+// send delivers tick, replacing an unread one so the receiver always sees
+// the latest code
+func (t *Ticker) send(out chan Tick, tick Tick) {
+	select {
+	case out <- tick:
+	default:
+		select {
+		case <-out:
+		default:
+		}
+		out <- tick
+	}
+}
@@ -0,0 +1,170 @@
+package totp
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock; its timer reports every Reset
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+	tm  *fakeTimer
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func (c *fakeClock) NewTimer(time.Duration) timer { return c.tm }
+
+type fakeTimer struct {
+	c      chan time.Time
+	resets chan time.Duration
+}
+
+func (f *fakeTimer) C() <-chan time.Time { return f.c }
+func (f *fakeTimer) Reset(d time.Duration) bool {
+	f.resets <- d
+	return true
+}
+func (f *fakeTimer) Stop() bool { return true }
+
+func Test_Ticker_PhaseLocked(t *testing.T) {
+	o, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clk := &fakeClock{
+		now: time.Unix(1111111100, 0), // 20s into a window
+		tm:  &fakeTimer{c: make(chan time.Time), resets: make(chan time.Duration, 1)},
+	}
+	tk := newTicker(o, clk)
+	defer tk.Stop()
+
+	// Each step: how late the timer fires past the scheduled instant,
+	// e.g. because the process slept
+	lateness := []time.Duration{0, 3 * time.Second, 75 * time.Second, 0}
+	for i, late := range lateness {
+		tick := <-tk.C
+		if tick.WindowStart.Unix()%30 != 0 {
+			t.Fatalf("step %d: window start %d not aligned", i, tick.WindowStart.Unix())
+		}
+		want, _ := GetTokenAt(rfc6238Secret, tick.WindowStart)
+		if tick.Code != want {
+			t.Fatalf("step %d: code %q, want %q", i, tick.Code, want)
+		}
+
+		d := <-clk.tm.resets
+		fire := clk.Now().Add(d)
+		if fire.Unix()%30 != 0 || fire.Nanosecond() != 0 {
+			t.Fatalf("step %d: timer set to fire at %v, not on a boundary", i, fire)
+		}
+		if fire.Unix() != tick.WindowStart.Unix()+30 {
+			t.Fatalf("step %d: fires at %d, want %d", i, fire.Unix(), tick.WindowStart.Unix()+30)
+		}
+		clk.set(fire.Add(late))
+		clk.tm.c <- clk.Now()
+	}
+}
+
+func Test_Ticker_EarlyFireRearms(t *testing.T) {
+	o, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clk := &fakeClock{
+		now: time.Unix(1111111100, 0),
+		tm:  &fakeTimer{c: make(chan time.Time), resets: make(chan time.Duration, 1)},
+	}
+	tk := newTicker(o, clk)
+	defer tk.Stop()
+
+	<-tk.C
+	<-clk.tm.resets
+	// Fire one second before the boundary: no tick, timer re-armed for 1s
+	clk.set(time.Unix(1111111109, 0))
+	clk.tm.c <- clk.Now()
+	if d := <-clk.tm.resets; d != time.Second {
+		t.Fatalf("re-armed for %v, want 1s", d)
+	}
+	select {
+	case tick := <-tk.C:
+		t.Fatalf("unexpected early tick %+v", tick)
+	default:
+	}
+
+	clk.set(time.Unix(1111111110, 0))
+	clk.tm.c <- clk.Now()
+	if tick := <-tk.C; tick.WindowStart.Unix() != 1111111110 {
+		t.Fatalf("window start %d, want 1111111110", tick.WindowStart.Unix())
+	}
+}
+
+func Test_NewTicker_Stop(t *testing.T) {
+	tk, err := NewTicker(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tick := <-tk.C
+	if len(tick.Code) != 6 {
+		t.Fatalf("unexpected code %q", tick.Code)
+	}
+	tk.Stop()
+	tk.Stop() // idempotent
+}
+
+func Test_NewTicker_WithClock(t *testing.T) {
+	tk, err := NewTicker(rfc6238Secret, WithClock(func() time.Time { return time.Unix(59, 0) }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tk.Stop()
+	tick := <-tk.C
+	if tick.Code != "287082" || !tick.WindowStart.Equal(time.Unix(30, 0)) {
+		t.Fatalf("got %+v, want 287082 from %v", tick, time.Unix(30, 0))
+	}
+}
+
+func Test_Ticker_ClockStepsBackward(t *testing.T) {
+	o, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clk := &fakeClock{
+		now: time.Unix(1111111100, 0),
+		tm:  &fakeTimer{c: make(chan time.Time), resets: make(chan time.Duration, 1)},
+	}
+	tk := newTicker(o, clk)
+	defer tk.Stop()
+
+	if tick := <-tk.C; tick.WindowStart.Unix() != 1111111080 {
+		t.Fatalf("window start %d, want 1111111080", tick.WindowStart.Unix())
+	}
+	<-clk.tm.resets
+
+	// The clock is stepped back 65s before the timer fires
+	clk.set(time.Unix(1111111045, 0))
+	clk.tm.c <- clk.Now()
+
+	tick := <-tk.C
+	if tick.WindowStart.Unix() != 1111111020 {
+		t.Fatalf("window start %d, want 1111111020", tick.WindowStart.Unix())
+	}
+	want, _ := GetTokenAt(rfc6238Secret, tick.WindowStart)
+	if tick.Code != want {
+		t.Fatalf("code %q, want %q", tick.Code, want)
+	}
+	if d := <-clk.tm.resets; d != 5*time.Second {
+		t.Fatalf("re-armed for %v, want 5s (next boundary 1111111050)", d)
+	}
+}
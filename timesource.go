@@ -0,0 +1,55 @@
+package totp
+
+import (
+	"context"
+	"time"
+)
+
+// TimeSource
+// Provide the time used for generation, e.g. from NTP or an HTTP Date header
+// on devices whose clock cannot be trusted
+type TimeSource interface {
+	Now() (time.Time, error)
+}
+
+// SystemTime
+// The default TimeSource: the local clock. It never returns an error.
+type SystemTime struct{}
+
+// Now returns the current local time in UTC
+func (SystemTime) Now() (time.Time, error) {
+	return timeNow(), nil
+}
+
+// GetTokenContext
+// Generate the current code using the time reported by src (SystemTime when
+// nil). Returns ctx.Err() if ctx is done before src answers; a slow src keeps
+// running in the background until it returns.
+func GetTokenContext(ctx context.Context, secretKey string, src TimeSource) (string, error) {
+	if src == nil {
+		src = SystemTime{}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	type result struct {
+		t   time.Time
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		t, err := src.Now()
+		ch <- result{t: t, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-ch:
+		if r.err != nil {
+			return "", r.err
+		}
+		return GetTokenAt(secretKey, r.t)
+	}
+}
@@ -0,0 +1,64 @@
+package totp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fixedTimeSource struct {
+	t   time.Time
+	err error
+}
+
+func (f fixedTimeSource) Now() (time.Time, error) { return f.t, f.err }
+
+// blockingTimeSource never answers until release is closed
+type blockingTimeSource struct{ release chan struct{} }
+
+func (b blockingTimeSource) Now() (time.Time, error) {
+	<-b.release
+	return time.Time{}, nil
+}
+
+func Test_GetTokenContext_FixedSource(t *testing.T) {
+	code, err := GetTokenContext(context.Background(), rfc6238Secret, fixedTimeSource{t: time.Unix(59, 0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+}
+
+func Test_GetTokenContext_SourceError(t *testing.T) {
+	want := errors.New("ntp unreachable")
+	_, err := GetTokenContext(context.Background(), rfc6238Secret, fixedTimeSource{err: want})
+	if !errors.Is(err, want) {
+		t.Fatalf("got %v, want %v", err, want)
+	}
+}
+
+func Test_GetTokenContext_Cancelled(t *testing.T) {
+	src := blockingTimeSource{release: make(chan struct{})}
+	defer close(src.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := GetTokenContext(ctx, rfc6238Secret, src)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func Test_GetTokenContext_DefaultSource(t *testing.T) {
+	pinTime(t, time.Unix(59, 0))
+	code, err := GetTokenContext(context.Background(), rfc6238Secret, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+}
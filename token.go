@@ -0,0 +1,55 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"time"
+)
+
+// Token
+// A generated or submitted code, kept distinct from arbitrary strings. It
+// holds the digits exactly as configured (zero padding, or none under
+// WithoutLeadingZeros), so String needs no further formatting and
+// conversions to and from string are plain type conversions.
+type Token string
+
+// ParseToken
+// Turn user input into a Token, removing spaces, dashes and invisible
+// characters as verification does; anything but digits left over fails
+// with ErrNonDigitToken
+func ParseToken(s string) (Token, error) {
+	s, err := cleanToken(s)
+	if err != nil {
+		return "", err
+	}
+	return Token(s), nil
+}
+
+// String returns the digits of the code
+func (t Token) String() string {
+	return string(t)
+}
+
+// Grouped
+// Split the code into groups for display, as FormatToken does
+func (t Token) Grouped(opts FormatOptions) (string, error) {
+	return FormatToken(string(t), opts)
+}
+
+// Equal
+// Report whether two codes are the same, in constant time
+func (t Token) Equal(u Token) bool {
+	return subtle.ConstantTimeCompare([]byte(t), []byte(u)) == 1
+}
+
+// TokenValueAt
+// Like TokenAt, but return the code as a Token
+func (o *TOTP) TokenValueAt(t time.Time) (Token, error) {
+	code, err := o.TokenAt(t)
+	return Token(code), err
+}
+
+// ValidateTokenAt
+// Like ValidateAt, for a Token
+func (o *TOTP) ValidateTokenAt(token Token, t time.Time, skew int) (bool, error) {
+	return o.ValidateAt(string(token), t, skew)
+}
@@ -0,0 +1,46 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_Token(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	o, err := New(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok, err := o.TokenValueAt(at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(tok); got != "081804" {
+		t.Fatalf("got %q, want %q", got, "081804")
+	}
+	if got, _ := tok.Grouped(FormatOptions{GroupSize: 3, Separator: " "}); got != "081 804" {
+		t.Fatalf("got %q, want %q", got, "081 804")
+	}
+	if ok, err := o.ValidateTokenAt(tok, at, 0); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+
+	parsed, err := ParseToken(" 081-804 ")
+	if err != nil || !parsed.Equal(tok) {
+		t.Fatalf("got (%q, %v), want %q", parsed, err, tok)
+	}
+	if tok.Equal("081805") {
+		t.Fatal("different codes compared equal")
+	}
+	if _, err := ParseToken("08180x"); !errors.Is(err, ErrNonDigitToken) {
+		t.Fatalf("got %v, want ErrNonDigitToken", err)
+	}
+
+	// The configured padding is part of the value
+	stripped, _ := New(rfc6238Secret, WithoutLeadingZeros())
+	if tok, _ := stripped.TokenValueAt(at); tok.String() != "81804" {
+		t.Fatalf("got %q, want %q", tok, "81804")
+	}
+}
@@ -1,20 +1,43 @@
 package totp
 
 import (
-	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base32"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
+const (
+	// defaultPeriod is the TOTP time step in seconds (RFC 6238 default)
+	defaultPeriod = 30
+	// defaultDigits is the default code length
+	defaultDigits = 6
+	// digitsModulo is 10^6 for 6-digit codes
+	digitsModulo = 1_000_000
+)
+
+// All window math in this package works on Unix seconds, so the location of
+// an input time.Time never changes which window it falls into, and times
+// returned by the package are in UTC. Times before the Unix epoch have no
+// counter and are rejected with ErrTimeBeforeEpoch.
+
+// timeNow is the clock used by the functions working on the current time;
+// tests replace it to pin the time
+var timeNow = func() time.Time { return time.Now().UTC() }
+
 // GetToken
 // Generate token from input MFA Secret key
 func GetToken(secretKey string) (string, error) {
-	now := time.Now().UTC().Unix()
-	code, err := generateTOTP(secretKey, now)
+	return GetTokenAt(secretKey, timeNow())
+}
+
+// GetTokenAt
+// Generate token from input MFA Secret key for time t
+func GetTokenAt(secretKey string, t time.Time) (string, error) {
+	code, err := generateTOTP(secretKey, t.Unix())
 	if err != nil {
 		return "", err
 	}
@@ -22,36 +45,279 @@ func GetToken(secretKey string) (string, error) {
 	return fmt.Sprintf("%06d", code), nil
 }
 
+// ErrTimeBeforeEpoch is returned for times before the epoch T0 (the Unix
+// epoch unless set with WithEpoch), which have no counter
+var ErrTimeBeforeEpoch = errors.New("time is before the TOTP epoch")
+
+// CounterAt
+// Return the time-step counter of the window containing t
+func CounterAt(t time.Time) (uint64, error) {
+	return counterFor(t.Unix(), defaultPeriod)
+}
+
+// counterFor
+// Return the counter for a Unix timestamp and period, rejecting pre-epoch
+// times instead of letting them wrap around as unsigned values
+func counterFor(timestamp, period int64) (uint64, error) {
+	if timestamp < 0 {
+		return 0, ErrTimeBeforeEpoch
+	}
+	return uint64(timestamp / period), nil
+}
+
+// GetTokenAtCounter
+// Generate token for an explicit time-step counter (as returned by CounterAt).
+// This is the same computation as GetHOTP.
+func GetTokenAtCounter(secretKey string, counter uint64) (string, error) {
+	return GetHOTP(secretKey, counter)
+}
+
+// CurrentWindow
+// Return the current counter and its code from a single clock read, so the
+// two always correspond even at a window boundary
+func CurrentWindow(secretKey string) (counter uint64, code string, err error) {
+	counter, err = CounterAt(timeNow())
+	if err != nil {
+		return 0, "", err
+	}
+	code, err = GetTokenAtCounter(secretKey, counter)
+	if err != nil {
+		return 0, "", err
+	}
+	return counter, code, nil
+}
+
+// CurrentAndNext
+// Generate the current code and the one from the following window, using a
+// single clock read so both always belong to adjacent windows. Combine with
+// RemainingSeconds to decide which one to emphasize around a rollover.
+func CurrentAndNext(secretKey string) (current, next string, err error) {
+	t := timeNow()
+	current, err = GetTokenAt(secretKey, t)
+	if err != nil {
+		return "", "", err
+	}
+	next, err = GetTokenAt(secretKey, t.Add(defaultPeriod*time.Second))
+	if err != nil {
+		return "", "", err
+	}
+	return current, next, nil
+}
+
+// GetFreshToken
+// Generate a code with at least minRemaining of validity left, for clients
+// that submit over slow links: the current code if enough of its window
+// remains, otherwise the next window's code. The returned duration is how
+// long the returned code stays valid. minRemaining must be between 0 and the
+// period.
+func GetFreshToken(secretKey string, minRemaining time.Duration) (string, time.Duration, error) {
+	if minRemaining < 0 || minRemaining > defaultPeriod*time.Second {
+		return "", 0, fmt.Errorf("minRemaining must be between 0 and %ds, got %v", defaultPeriod, minRemaining)
+	}
+	t := timeNow()
+	_, until, err := ValidFromUntil(secretKey, t)
+	if err != nil {
+		return "", 0, err
+	}
+	remaining := until.Sub(t)
+	if remaining < minRemaining {
+		t = until
+		remaining += defaultPeriod * time.Second
+	}
+	code, err := GetTokenAt(secretKey, t)
+	if err != nil {
+		return "", 0, err
+	}
+	return code, remaining, nil
+}
+
+// NextToken
+// Generate the code of the window after the current one
+func NextToken(secretKey string) (string, error) {
+	return GetTokenAfter(secretKey, defaultPeriod*time.Second)
+}
+
+// PrevToken
+// Generate the code of the window before the current one
+func PrevToken(secretKey string) (string, error) {
+	return GetTokenAfter(secretKey, -defaultPeriod*time.Second)
+}
+
+// GetTokenAfter
+// Generate the code valid at now+d; negative durations give past codes
+func GetTokenAfter(secretKey string, d time.Duration) (string, error) {
+	return GetTokenAt(secretKey, timeNow().Add(d))
+}
+
+// GetTokenWithDeviceOffset
+// Diagnostic: generate the code a device whose clock runs offset ahead of
+// ours (behind, if negative) currently displays. It is GetTokenAfter, named
+// for support staff who know how far off a user's clock is.
+func GetTokenWithDeviceOffset(secretKey string, offset time.Duration) (string, error) {
+	return GetTokenAfter(secretKey, offset)
+}
+
+// GetTokenAtMillis
+// Generate token for a Unix timestamp in milliseconds (e.g. JavaScript Date.now())
+func GetTokenAtMillis(secretKey string, unixMillis int64) (string, error) {
+	return GetTokenAt(secretKey, time.UnixMilli(unixMillis))
+}
+
+// TokenBytes
+// Generate the code for time t as zero-padded ASCII digits in a fixed array,
+// without allocating a string. The int is the number of bytes used.
+func TokenBytes(secretKey string, t time.Time) ([8]byte, int, error) {
+	var buf [8]byte
+	code, err := generateTOTP(secretKey, t.Unix())
+	if err != nil {
+		return buf, 0, err
+	}
+	const n = 6
+	writeDigits(buf[:n], code)
+	return buf, n, nil
+}
+
+// TruncatedValue
+// Return the 31-bit dynamically truncated value for time t, before the
+// 10^digits modulo (RFC 4226 section 5.3, step 2), for cross-checking the
+// truncation math
+func TruncatedValue(secretKey string, t time.Time) (uint32, error) {
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return 0, err
+	}
+	counter, err := CounterAt(t)
+	if err != nil {
+		return 0, err
+	}
+	return dynamicTruncate(hmacCounter(secretBytes, counter)), nil
+}
+
+// RemainingSeconds
+// Return the number of seconds left in the window containing t
+func RemainingSeconds(t time.Time) int {
+	return int(defaultPeriod - mod(t.Unix(), defaultPeriod))
+}
+
+// Progress
+// Return the elapsed fraction of the window containing t, in [0, 1): 0 at a
+// boundary, approaching 1 just before the next one. It is the complement of
+// RemainingSeconds with sub-second precision, for countdown rings.
+func Progress(t time.Time) float64 {
+	elapsed := float64(mod(t.Unix(), defaultPeriod)) + float64(t.Nanosecond())/1e9
+	return elapsed / defaultPeriod
+}
+
+// ValidFromUntil
+// Return the [from, until) interval during which the code shown at t is the
+// active one, in UTC. The secret is only checked for validity.
+func ValidFromUntil(secretKey string, t time.Time) (from, until time.Time, err error) {
+	if _, err := decodeSecret(secretKey); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if t.Unix() < 0 {
+		return time.Time{}, time.Time{}, ErrTimeBeforeEpoch
+	}
+	start := t.Unix() - mod(t.Unix(), defaultPeriod)
+	return time.Unix(start, 0).UTC(), time.Unix(start+defaultPeriod, 0).UTC(), nil
+}
+
 // generateTOTP function
 func generateTOTP(secretKey string, timestamp int64) (uint32, error) {
+	secretBytes, err := decodeSecret(secretKey)
+	if err != nil {
+		return 0, err
+	}
+	counter, err := counterFor(timestamp, defaultPeriod)
+	if err != nil {
+		return 0, err
+	}
+	return hotp(secretBytes, counter), nil
+}
+
+// decodeSecret
+// The base32 encoded secret key string is decoded to a byte slice
+func decodeSecret(secretKey string) ([]byte, error) {
+	return decodeSecretWith(secretKey, nil)
+}
+
+// secretEncoding is the default secret encoding: StdEncoding without padding
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
 
-	// The base32 encoded secret key string is decoded to a byte slice
-	base32Decoder := base32.StdEncoding.WithPadding(base32.NoPadding)
-	secretKey = strings.ToUpper(strings.TrimSpace(secretKey)) // preprocess
-	secretBytes, err := base32Decoder.DecodeString(secretKey) // decode
+// decodeSecretWith
+// Decode the secret with a custom base32 encoding; nil selects the default
+// StdEncoding without padding. Custom encodings only get whitespace trimmed,
+// since their alphabet may be case-sensitive.
+func decodeSecretWith(secretKey string, encoding *base32.Encoding) ([]byte, error) {
+	if encoding == nil {
+		encoding = secretEncoding
+		secretKey = normalizeSecret(secretKey) // preprocess
+	} else {
+		secretKey = strings.TrimSpace(secretKey)
+	}
+	secretBytes, err := encoding.DecodeString(secretKey) // decode
 	if err != nil {
-		return 0, fmt.Errorf("invalid base32 secret: %w", err)
+		return nil, fmt.Errorf("invalid base32 secret: %w", err)
 	}
+	return secretBytes, nil
+}
 
-	// The truncated timestamp / 30 is converted to an 8-byte big-endian
-	// unsigned integer slice
-	timeBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(timeBytes, uint64(timestamp)/30)
+// normalizeSecret
+// Canonicalize a base32 secret before decoding: uppercase, without
+// whitespace (grouped display like "GEZD GNBV") and without "=" padding.
+// Every code path that takes a secret string (generation, validation and URI
+// parsing alike) goes through here, so all spellings produce the same codes.
+func normalizeSecret(secretKey string) string {
+	secretKey = strings.Join(strings.Fields(secretKey), "")
+	return strings.ToUpper(strings.TrimRight(secretKey, "="))
+}
 
-	// The timestamp bytes are concatenated with the decoded secret key
-	// bytes. Then a 20-byte SHA-1 hash is calculated from the byte slice
-	hash := hmac.New(sha1.New, secretBytes)
-	hash.Write(timeBytes) // Concat the timestamp byte slice
-	h := hash.Sum(nil)    // Calculate 20-byte SHA-1 digest
+// typoReplacer maps the digits base32 leaves out to the letters they are
+// mistaken for
+var typoReplacer = strings.NewReplacer("0", "O", "1", "I")
 
-	// AND the SHA-1 with 0x0F (15) to get a single-digit offset
-	offset := h[len(h)-1] & 0x0F
+// correctTypos
+// Replace 0 and 1 in a hand-typed secret with O and I, see WithTypoCorrection
+func correctTypos(secretKey string) string {
+	return typoReplacer.Replace(secretKey)
+}
 
-	// Truncate the SHA-1 by the offset and convert it into a 32-bit
+// hotp
+// Calculate the 6-digit HOTP value (RFC 4226) for a decoded key and counter
+func hotp(secretBytes []byte, counter uint64) uint32 {
+	// Take modulo 1_000_000 to get a 6-digit code
+	return dynamicTruncate(hmacCounter(secretBytes, counter)) % digitsModulo
+}
+
+// hmacCounter
+// Calculate the HMAC-SHA1 digest of the counter keyed by the decoded secret
+func hmacCounter(secretBytes []byte, counter uint64) []byte {
+	return hmacCounterWith(sha1.New, secretBytes, counter)
+}
+
+// dynamicTruncate
+// Extract a 31-bit unsigned int from an HMAC digest (RFC 4226, section 5.3)
+func dynamicTruncate(h []byte) uint32 {
+	offset := truncationOffset(h)
+
+	// Truncate the digest by the offset and convert it into a 32-bit
 	// unsigned int. AND the 32-bit int with 0x7FFFFFFF (2147483647)
 	// to get a 31-bit unsigned int.
-	truncatedHash := binary.BigEndian.Uint32(h[offset:offset+4]) & 0x7FFFFFFF
+	return binary.BigEndian.Uint32(h[offset:offset+4]) & 0x7FFFFFFF
+}
 
-	// Take modulo 1_000_000 to get a 6-digit code
-	return truncatedHash % 1_000_000, nil
+// truncationOffset
+// AND the last byte with 0x0F (15) to get a single-digit offset
+func truncationOffset(h []byte) int {
+	return int(h[len(h)-1] & 0x0F)
+}
+
+// mod
+// Floored modulo, so negative timestamps still land in [0, m)
+func mod(a, m int64) int64 {
+	r := a % m
+	if r < 0 {
+		r += m
+	}
+	return r
 }
@@ -115,3 +115,122 @@ func Benchmark_zeroPadFormatting(b *testing.B) {
 		_ = fmt.Sprintf("%06d", code)
 	}
 }
+
+// Benchmark TOTP.TokenAt with the default parameters, which takes the fast path.
+func Benchmark_TOTP_TokenAt_Default(b *testing.B) {
+	b.ReportAllocs()
+	o, err := New(benchSecret)
+	if err != nil {
+		b.Fatal(err)
+	}
+	at := time.Unix(1234567890, 0)
+	for b.Loop() {
+		if _, err := o.TokenAt(at); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark TOTP.TokenAt with a non-default config, which takes the generalized path.
+func Benchmark_TOTP_TokenAt_Configured(b *testing.B) {
+	b.ReportAllocs()
+	o, err := New(benchSecret, WithChecksum())
+	if err != nil {
+		b.Fatal(err)
+	}
+	at := time.Unix(1234567890, 0)
+	for b.Loop() {
+		if _, err := o.TokenAt(at); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark the fast path on its own.
+func Benchmark_fastToken(b *testing.B) {
+	b.ReportAllocs()
+	o, err := New(benchSecret)
+	if err != nil {
+		b.Fatal(err)
+	}
+	counter := uint64(1234567890 / 30)
+	for b.Loop() {
+		_ = fastToken(o.secret, counter)
+	}
+}
+
+// Baseline for Benchmark_fastToken: the generalized path on the same (default) parameters.
+func Benchmark_tokenGeneral(b *testing.B) {
+	b.ReportAllocs()
+	o, err := New(benchSecret)
+	if err != nil {
+		b.Fatal(err)
+	}
+	counter := uint64(1234567890 / 30)
+	for b.Loop() {
+		_ = o.tokenGeneral(counter)
+	}
+}
+
+// Benchmark VerifyFast with a skew of 1 (three windows).
+func Benchmark_VerifyFast(b *testing.B) {
+	b.ReportAllocs()
+	token, err := GetToken(benchSecret)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		if _, err := VerifyFast(benchSecret, token, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Baseline for Benchmark_VerifyFast: a naive loop of GetTokenAt over the same windows.
+func Benchmark_VerifyNaive(b *testing.B) {
+	b.ReportAllocs()
+	token, err := GetToken(benchSecret)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		now := time.Now().UTC()
+		for offset := -1; offset <= 1; offset++ {
+			code, err := GetTokenAt(benchSecret, now.Add(time.Duration(offset)*30*time.Second))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if code == token {
+				break
+			}
+		}
+	}
+}
+
+// Benchmark code generation for each algorithm with its RFC 6238 seed (20,
+// 32 and 64 bytes), so the relative cost of the hash functions is
+// measurable. tokenGeneral is called directly: TokenAt would send the SHA1
+// case down the default-parameter fast path and skew the comparison.
+func Benchmark_TOTP_TokenAt_Algorithm(b *testing.B) {
+	seeds := map[Algorithm]string{
+		SHA1:   "12345678901234567890",
+		SHA256: "12345678901234567890123456789012",
+		SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	for _, a := range []Algorithm{SHA1, SHA256, SHA512} {
+		b.Run(a.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			o, err := New(EncodeSecret([]byte(seeds[a])), WithAlgorithm(a), WithRFCStrict())
+			if err != nil {
+				b.Fatal(err)
+			}
+			counter, err := o.counterAt(1234567890)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				o.tokenGeneral(counter)
+			}
+		})
+	}
+}
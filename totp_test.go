@@ -1,9 +1,15 @@
 package totp
 
 import (
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"testing"
+	"time"
 )
 
 // RFC 6238 SHA-1 vectors (8-digit OTPs):
@@ -43,6 +49,46 @@ func Test_generateTOTP_RFC6238_SHA1_Last6(t *testing.T) {
 	}
 }
 
+func Test_RFC6238_SHA1_EightDigits(t *testing.T) {
+	// The full 8-digit values from the table above, end to end: generation,
+	// validation and the default 4+4 grouping
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	o, err := New(rfc6238Secret, WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for ts, want := range vectors {
+		at := time.Unix(ts, 0)
+		got, err := o.TokenAt(at)
+		if err != nil || got != want {
+			t.Fatalf("T=%d: got (%q, %v), want %q", ts, got, err, want)
+		}
+		if ok, err := o.ValidateAt(want, at, 0); err != nil || !ok {
+			t.Fatalf("T=%d: validate got (%v, %v), want (true, nil)", ts, ok, err)
+		}
+		grouped, _ := FormatToken(got, DefaultFormatOptions(8))
+		if grouped != want[:4]+" "+want[4:] {
+			t.Fatalf("T=%d: got %q, want 4+4 grouping", ts, grouped)
+		}
+		if ok, err := o.ValidateAt(grouped, at, 0); err != nil || !ok {
+			t.Fatalf("T=%d: grouped validate got (%v, %v), want (true, nil)", ts, ok, err)
+		}
+	}
+	if _, err := o.ValidateAt("081804", time.Unix(1111111109, 0), 0); !errors.Is(err, ErrDigitMismatch) {
+		t.Fatalf("6 digits against an 8-digit config: got %v, want ErrDigitMismatch", err)
+	}
+	if got := DefaultFormatOptions(6); got.GroupSize != 3 || got.Separator != " " {
+		t.Fatalf("6 digits: got %+v, want 3+3", got)
+	}
+}
+
 func Test_generateTOTP_InvalidSecret(t *testing.T) {
 	// Not valid base32
 	_, err := generateTOTP("not*base32==", 59)
@@ -84,3 +130,392 @@ func Test_Padding(t *testing.T) {
 		t.Fatalf("padded output mismatch: got %q, want %q", padded, "081804")
 	}
 }
+
+// referenceHOTP computes an HOTP value following RFC 2104 by hand
+// (no crypto/hmac), so key handling can be checked independently.
+func referenceHOTP(key []byte, counter uint64) uint32 {
+	const blockSize = 64
+	if len(key) > blockSize {
+		sum := sha1.Sum(key)
+		key = sum[:]
+	}
+	padded := make([]byte, blockSize)
+	copy(padded, key)
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	inner := sha1.New()
+	outer := sha1.New()
+	for _, b := range padded {
+		inner.Write([]byte{b ^ 0x36})
+		outer.Write([]byte{b ^ 0x5c})
+	}
+	inner.Write(msg)
+	outer.Write(inner.Sum(nil))
+	h := outer.Sum(nil)
+
+	offset := h[len(h)-1] & 0x0F
+	return (binary.BigEndian.Uint32(h[offset:offset+4]) & 0x7FFFFFFF) % 1_000_000
+}
+
+func Test_generateTOTP_LongSecret(t *testing.T) {
+	// 100-byte key, longer than the SHA-1 block size (64 bytes).
+	key := make([]byte, 100)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
+
+	// Expected values computed with Python's hmac module.
+	vectors := []vector{
+		{timestamp: 59, want6: 695482},
+		{timestamp: 1111111109, want6: 643866},
+		{timestamp: 2000000000, want6: 139610},
+	}
+
+	for _, tc := range vectors {
+		got, err := generateTOTP(secret, tc.timestamp)
+		if err != nil {
+			t.Fatalf("timestamp=%d: unexpected error: %v", tc.timestamp, err)
+		}
+		if got != tc.want6 {
+			t.Fatalf("timestamp=%d: got %d, want %d", tc.timestamp, got, tc.want6)
+		}
+		if ref := referenceHOTP(key, uint64(tc.timestamp)/30); got != ref {
+			t.Fatalf("timestamp=%d: got %d, reference %d", tc.timestamp, got, ref)
+		}
+	}
+}
+
+func Test_RemainingSeconds(t *testing.T) {
+	cases := map[int64]int{
+		0:  30,
+		1:  29,
+		29: 1,
+		30: 30,
+		59: 1,
+	}
+	for ts, want := range cases {
+		if got := RemainingSeconds(time.Unix(ts, 0)); got != want {
+			t.Fatalf("ts=%d: got %d, want %d", ts, got, want)
+		}
+	}
+}
+
+func Test_ValidFromUntil(t *testing.T) {
+	cases := []struct {
+		ts        int64
+		wantFrom  int64
+		wantUntil int64
+	}{
+		{ts: 0, wantFrom: 0, wantUntil: 30},
+		{ts: 29, wantFrom: 0, wantUntil: 30},
+		{ts: 30, wantFrom: 30, wantUntil: 60},
+		{ts: 1111111109, wantFrom: 1111111080, wantUntil: 1111111110},
+		{ts: 1111111110, wantFrom: 1111111110, wantUntil: 1111111140},
+	}
+	for _, tc := range cases {
+		from, until, err := ValidFromUntil(rfc6238Secret, time.Unix(tc.ts, 500))
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", tc.ts, err)
+		}
+		if from.Unix() != tc.wantFrom || until.Unix() != tc.wantUntil {
+			t.Fatalf("ts=%d: got [%d, %d), want [%d, %d)", tc.ts, from.Unix(), until.Unix(), tc.wantFrom, tc.wantUntil)
+		}
+		if until.Sub(from) != 30*time.Second {
+			t.Fatalf("ts=%d: window length %v, want 30s", tc.ts, until.Sub(from))
+		}
+	}
+}
+
+func Test_ValidFromUntil_InvalidSecret(t *testing.T) {
+	if _, _, err := ValidFromUntil("not*base32==", time.Unix(59, 0)); err == nil {
+		t.Fatal("expected error for invalid base32 secret, got nil")
+	}
+}
+
+func Test_GetTokenAt_RFC6238(t *testing.T) {
+	code, err := GetTokenAt(rfc6238Secret, time.Unix(1111111109, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "081804" {
+		t.Fatalf("got %q, want %q", code, "081804")
+	}
+}
+
+func Test_TokenBytes_MatchesGetTokenAt(t *testing.T) {
+	for _, ts := range []int64{59, 1111111109, 1234567890, 2000000000} {
+		at := time.Unix(ts, 0)
+		buf, n, err := TokenBytes(rfc6238Secret, at)
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		want, err := GetTokenAt(rfc6238Secret, at)
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Fatalf("ts=%d: got %q, want %q", ts, got, want)
+		}
+	}
+}
+
+func Test_TruncatedValue(t *testing.T) {
+	// RFC 4226 appendix D lists the truncated values per counter for the
+	// same seed; T=0, 59 and 60 are counters 0, 1 and 2
+	cases := map[int64]uint32{0: 1284755224, 59: 1094287082, 60: 137359152}
+	for ts, want := range cases {
+		got, err := TruncatedValue(rfc6238Secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		if got != want {
+			t.Fatalf("ts=%d: got %d, want %d", ts, got, want)
+		}
+		if code, _ := GetTokenAt(rfc6238Secret, time.Unix(ts, 0)); fmt.Sprintf("%06d", got%1_000_000) != code {
+			t.Fatalf("ts=%d: %d mod 10^6 does not give %q", ts, got, code)
+		}
+	}
+	if _, err := TruncatedValue(rfc6238Secret, time.Unix(-1, 0)); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("got %v, want ErrTimeBeforeEpoch", err)
+	}
+}
+
+func Test_GetTokenAtMillis(t *testing.T) {
+	cases := map[int64]string{
+		59000: "287082", // RFC T=59
+		59999: "287082", // still inside the same second
+		60000: "359152", // next window
+	}
+	for millis, want := range cases {
+		got, err := GetTokenAtMillis(rfc6238Secret, millis)
+		if err != nil {
+			t.Fatalf("millis=%d: unexpected error: %v", millis, err)
+		}
+		if got != want {
+			t.Fatalf("millis=%d: got %q, want %q", millis, got, want)
+		}
+	}
+}
+
+// pinTime makes the package clock return t until the test ends
+func pinTime(t *testing.T, at time.Time) {
+	t.Helper()
+	orig := timeNow
+	timeNow = func() time.Time { return at }
+	t.Cleanup(func() { timeNow = orig })
+}
+
+func Test_CurrentAndNext_Boundary(t *testing.T) {
+	// Last second of the T=59 window: current expires in 1s
+	pinTime(t, time.Unix(59, 0))
+	current, next, err := CurrentAndNext(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current != "287082" || next != "359152" {
+		t.Fatalf("got (%q, %q), want (%q, %q)", current, next, "287082", "359152")
+	}
+	if r := RemainingSeconds(time.Unix(59, 0)); r != 1 {
+		t.Fatalf("remaining=%d, want 1", r)
+	}
+
+	// Exactly at the boundary the next code becomes current
+	pinTime(t, time.Unix(60, 0))
+	current, _, err = CurrentAndNext(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current != "359152" {
+		t.Fatalf("got %q, want %q", current, "359152")
+	}
+}
+
+func Test_CurrentWindow(t *testing.T) {
+	for _, ts := range []int64{59, 60, 1111111109, 1111111110} {
+		pinTime(t, time.Unix(ts, 0))
+		counter, code, err := CurrentWindow(rfc6238Secret)
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		if counter != uint64(ts)/30 {
+			t.Fatalf("ts=%d: counter=%d, want %d", ts, counter, ts/30)
+		}
+		again, err := GetTokenAtCounter(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", ts, err)
+		}
+		if again != code {
+			t.Fatalf("ts=%d: GetTokenAtCounter=%q, CurrentWindow=%q", ts, again, code)
+		}
+		want, _ := GetTokenAt(rfc6238Secret, time.Unix(ts, 0))
+		if code != want {
+			t.Fatalf("ts=%d: got %q, want %q", ts, code, want)
+		}
+	}
+}
+
+func Test_NonUTCInput(t *testing.T) {
+	// 1111111109 in a zone far from UTC; the wall clock reads a different day
+	zone := time.FixedZone("UTC+13:45", 13*3600+45*60)
+	local := time.Unix(1111111109, 0).In(zone)
+
+	code, err := GetTokenAt(rfc6238Secret, local)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "081804" {
+		t.Fatalf("got %q, want %q", code, "081804")
+	}
+
+	from, until, err := ValidFromUntil(rfc6238Secret, local)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from.Location() != time.UTC || until.Location() != time.UTC {
+		t.Fatalf("got locations %v/%v, want UTC", from.Location(), until.Location())
+	}
+	if from.Unix() != 1111111080 || until.Unix() != 1111111110 {
+		t.Fatalf("got [%d, %d), want [1111111080, 1111111110)", from.Unix(), until.Unix())
+	}
+
+	m, ok, err := ValidateDetailed(rfc6238Secret, "081804", local, 0)
+	if err != nil || !ok {
+		t.Fatalf("got (%v, %v), want match", ok, err)
+	}
+	if m.RemainingSeconds != 1 {
+		t.Fatalf("remaining=%d, want 1", m.RemainingSeconds)
+	}
+}
+
+func Test_PreEpochTimes(t *testing.T) {
+	before := time.Unix(-1, 0)
+
+	if _, err := GetTokenAt(rfc6238Secret, before); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("GetTokenAt: got %v, want ErrTimeBeforeEpoch", err)
+	}
+	if _, err := CounterAt(before); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("CounterAt: got %v, want ErrTimeBeforeEpoch", err)
+	}
+	if _, _, err := ValidFromUntil(rfc6238Secret, before); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("ValidFromUntil: got %v, want ErrTimeBeforeEpoch", err)
+	}
+	if _, err := ValidateAt(rfc6238Secret, "755224", before, 1); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("ValidateAt: got %v, want ErrTimeBeforeEpoch", err)
+	}
+	if _, err := GetTokenAtMillis(rfc6238Secret, -1); !errors.Is(err, ErrTimeBeforeEpoch) {
+		t.Fatalf("GetTokenAtMillis: got %v, want ErrTimeBeforeEpoch", err)
+	}
+
+	// The epoch itself is the first second of counter 0
+	code, err := GetTokenAt(rfc6238Secret, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "755224" {
+		t.Fatalf("got %q, want %q", code, "755224")
+	}
+}
+
+func Test_GetTokenAfter(t *testing.T) {
+	pinTime(t, time.Unix(1111111100, 0))
+
+	next, err := NextToken(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	after, err := GetTokenAfter(rfc6238Secret, 30*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if after != next || after != "050471" {
+		t.Fatalf("GetTokenAfter(+30s)=%q, NextToken=%q, want %q", after, next, "050471")
+	}
+
+	prev, err := PrevToken(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before, err := GetTokenAfter(rfc6238Secret, -30*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if before != prev {
+		t.Fatalf("GetTokenAfter(-30s)=%q, PrevToken=%q", before, prev)
+	}
+	want, _ := GetTokenAt(rfc6238Secret, time.Unix(1111111070, 0))
+	if before != want {
+		t.Fatalf("got %q, want %q", before, want)
+	}
+}
+
+func Test_Progress(t *testing.T) {
+	if p := Progress(time.Unix(60, 0)); p != 0 {
+		t.Fatalf("boundary: got %v, want 0", p)
+	}
+	if p := Progress(time.Unix(75, 0)); p != 0.5 {
+		t.Fatalf("midpoint: got %v, want 0.5", p)
+	}
+	p := Progress(time.Unix(89, 999_000_000))
+	if p >= 1 || p < 0.9999 {
+		t.Fatalf("end of window: got %v, want just below 1", p)
+	}
+	// Consistent with RemainingSeconds at whole seconds
+	at := time.Unix(1111111100, 0)
+	if got, want := Progress(at), 1-float64(RemainingSeconds(at))/30; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func Test_GetTokenWithDeviceOffset(t *testing.T) {
+	pinTime(t, time.Unix(1111111070, 0))
+	ahead, err := GetTokenWithDeviceOffset(rfc6238Secret, 60*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A device 60s ahead shows what NextToken gives one period from now
+	pinTime(t, time.Unix(1111111100, 0))
+	next, err := NextToken(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ahead != next || ahead != "050471" {
+		t.Fatalf("GetTokenWithDeviceOffset(+60s)=%q, NextToken=%q, want %q", ahead, next, "050471")
+	}
+}
+
+func Test_GetFreshToken(t *testing.T) {
+	// 1111111104 is 6s before the boundary at 1111111110
+	pinTime(t, time.Unix(1111111104, 0))
+	code, remaining, err := GetFreshToken(rfc6238Secret, 5*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "081804" || remaining != 6*time.Second {
+		t.Fatalf("6s left: got (%q, %v), want (%q, 6s)", code, remaining, "081804")
+	}
+
+	// 1111111106 leaves 4s, too few, so the next window's code is returned
+	pinTime(t, time.Unix(1111111106, 0))
+	code, remaining, err = GetFreshToken(rfc6238Secret, 5*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "050471" || remaining != 34*time.Second {
+		t.Fatalf("4s left: got (%q, %v), want (%q, 34s)", code, remaining, "050471")
+	}
+
+	// Just after the boundary the new window has almost all of its time left
+	pinTime(t, time.Unix(1111111110, 0))
+	code, remaining, _ = GetFreshToken(rfc6238Secret, 5*time.Second)
+	if code != "050471" || remaining != 30*time.Second {
+		t.Fatalf("after boundary: got (%q, %v), want (%q, 30s)", code, remaining, "050471")
+	}
+
+	if _, _, err := GetFreshToken(rfc6238Secret, time.Minute); err == nil {
+		t.Fatalf("expected error for minRemaining above the period")
+	}
+}
@@ -0,0 +1,293 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidURI is returned when an otpauth URI cannot be parsed
+var ErrInvalidURI = errors.New("invalid otpauth URI")
+
+// Key
+// The content of an otpauth:// provisioning URI
+type Key struct {
+	// Type is "totp" or "hotp"
+	Type    string
+	Issuer  string
+	Account string
+	// Secret is the base32 secret in canonical form (uppercase, no padding)
+	Secret string
+	// Period is the TOTP time step; zero for HOTP keys
+	Period    time.Duration
+	Algorithm Algorithm
+	// Digits is the code length, 6 to 8; zero means 6
+	Digits int
+	// Counter is the initial HOTP counter; zero for TOTP keys
+	Counter uint64
+}
+
+// ParseURI
+// Parse an otpauth://totp/ or otpauth://hotp/ URI in the Google
+// Authenticator key-uri format. HOTP URIs must carry the counter parameter.
+// The secret goes through the same normalization as GetToken, so padded,
+// lowercase and spaced secrets are accepted. Parameters this package cannot
+// honor (an unknown algorithm, or digits outside 6 to 8) are rejected
+// rather than ignored. When options are given the key is checked against them as
+// New would, so a policy such as WithAllowedAlgorithms rejects the URI here.
+func ParseURI(uri string, opts ...Option) (*Key, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("%w: scheme %q, want otpauth", ErrInvalidURI, u.Scheme)
+	}
+	if u.Host != "totp" && u.Host != "hotp" {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidURI, u.Host)
+	}
+
+	k := &Key{Type: u.Host, Digits: defaultDigits}
+	// Split on the literal separator before unescaping, so an escaped colon
+	// (%3A) stays part of the issuer or account
+	label := strings.TrimPrefix(u.EscapedPath(), "/")
+	issuer, account, ok := strings.Cut(label, ":")
+	if !ok {
+		issuer, account = "", label
+	}
+	if k.Issuer, err = url.PathUnescape(issuer); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	if k.Account, err = url.PathUnescape(account); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	k.Account = strings.TrimSpace(k.Account)
+
+	q := u.Query()
+	if issuer := q.Get("issuer"); issuer != "" {
+		k.Issuer = issuer
+	}
+
+	k.Secret = normalizeSecret(q.Get("secret"))
+	if k.Secret == "" {
+		return nil, fmt.Errorf("%w: missing secret", ErrInvalidURI)
+	}
+	if _, err := decodeSecret(k.Secret); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+
+	if k.Type == "hotp" {
+		v := q.Get("counter")
+		if v == "" {
+			return nil, fmt.Errorf("%w: missing counter", ErrInvalidURI)
+		}
+		if k.Counter, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: invalid counter %q", ErrInvalidURI, v)
+		}
+	} else {
+		k.Period = defaultPeriod * time.Second
+		if v := q.Get("period"); v != "" {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return nil, fmt.Errorf("%w: invalid period %q", ErrInvalidURI, v)
+			}
+			k.Period = time.Duration(seconds) * time.Second
+		}
+	}
+	if v := q.Get("algorithm"); v != "" {
+		a, err := ParseAlgorithm(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+		}
+		k.Algorithm = a
+	}
+	if v := q.Get("digits"); v != "" {
+		digits, err := strconv.Atoi(v)
+		if err != nil || digits < 6 || digits > 8 {
+			return nil, fmt.Errorf("%w: unsupported digits %q", ErrInvalidURI, v)
+		}
+		k.Digits = digits
+	}
+	if len(opts) > 0 {
+		if k.Type == "hotp" {
+			_, err = k.HOTP(opts...)
+		} else {
+			_, err = k.TOTP(opts...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// TOTP
+// Create a generator for a TOTP key
+func (k *Key) TOTP(opts ...Option) (*TOTP, error) {
+	if k.Type == "hotp" {
+		return nil, errors.New("key is an HOTP key, use Key.HOTP")
+	}
+	return New(k.Secret, append(k.options(WithPeriod(k.Period)), opts...)...)
+}
+
+// HOTP
+// Create a generator for an HOTP key. Start at k.Counter and persist the
+// counter as codes are used.
+func (k *Key) HOTP(opts ...Option) (*HOTP, error) {
+	if k.Type != "hotp" {
+		return nil, fmt.Errorf("key is a %s key, use Key.TOTP", k.Type)
+	}
+	return NewHOTP(k.Secret, append(k.options(), opts...)...)
+}
+
+// Params
+// Return the key's otpauth query parameters in canonical form: secret
+// (uppercase base32), algorithm ("SHA1", ...), digits, and period for TOTP
+// or counter for HOTP, plus issuer when set. For callers assembling their
+// own URIs; BuildURI and TOTP.URI cover the usual cases.
+func (k *Key) Params() map[string]string {
+	digits := k.Digits
+	if digits == 0 {
+		digits = defaultDigits
+	}
+	p := map[string]string{
+		"secret":    normalizeSecret(k.Secret),
+		"algorithm": k.Algorithm.String(),
+		"digits":    strconv.Itoa(digits),
+	}
+	if k.Type == "hotp" {
+		p["counter"] = strconv.FormatUint(k.Counter, 10)
+	} else {
+		p["period"] = strconv.FormatInt(int64(k.Period/time.Second), 10)
+	}
+	if k.Issuer != "" {
+		p["issuer"] = k.Issuer
+	}
+	return p
+}
+
+// options returns the options describing the key's parameters
+func (k *Key) options(extra ...Option) []Option {
+	opts := append(extra, WithAlgorithm(k.Algorithm))
+	if k.Digits != 0 {
+		opts = append(opts, WithDigits(k.Digits))
+	}
+	return opts
+}
+
+// URIOption
+// Configure a URI built by BuildURI
+type URIOption func(*uriConfig) error
+
+// uriConfig holds the settings collected from URI options
+type uriConfig struct {
+	image string
+}
+
+// WithImage
+// Add the nonstandard `image` parameter, an icon URL some authenticators
+// display next to the account. It must be an absolute http(s) URL.
+func WithImage(imageURL string) URIOption {
+	return func(c *uriConfig) error {
+		u, err := url.Parse(imageURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid image URL %q", imageURL)
+		}
+		c.image = imageURL
+		return nil
+	}
+}
+
+// BuildURI
+// Build an otpauth://totp/ provisioning URI for the secret, the inverse of
+// ParseURI
+func BuildURI(issuer, account, secretKey string, opts ...URIOption) (string, error) {
+	secret := normalizeSecret(secretKey)
+	if _, err := decodeSecret(secret); err != nil {
+		return "", err
+	}
+	return buildURI("totp", issuer, account, secret, url.Values{}, opts)
+}
+
+// BuildHOTPURI
+// Build an otpauth://hotp/ provisioning URI for the secret starting at
+// counter, the inverse of ParseURI for HOTP keys
+func BuildHOTPURI(issuer, account, secretKey string, counter uint64, opts ...URIOption) (string, error) {
+	secret := normalizeSecret(secretKey)
+	if _, err := decodeSecret(secret); err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("counter", strconv.FormatUint(counter, 10))
+	return buildURI("hotp", issuer, account, secret, q, opts)
+}
+
+// ErrNotExportable is returned when a TOTP uses parameters an otpauth URI
+// cannot express
+var ErrNotExportable = errors.New("configuration cannot be expressed as an otpauth URI")
+
+// URI
+// Build an otpauth://totp/ provisioning URI for the generator, including
+// its period, algorithm and digits when they differ from the defaults.
+// Nonstandard parameters (checksum, stripped zeros, counter encoding, width
+// or prefix, step multiplier, epoch) fail with ErrNotExportable, since
+// authenticators would silently ignore them.
+func (o *TOTP) URI(issuer, account string, opts ...URIOption) (string, error) {
+	p := o.params
+	p.period, p.algorithm, p.digits = defaultPeriod, SHA1, defaultDigits
+	if p != defaultParams {
+		return "", ErrNotExportable
+	}
+	q := url.Values{}
+	if o.period != defaultPeriod {
+		q.Set("period", strconv.FormatInt(o.period, 10))
+	}
+	if o.algorithm != SHA1 {
+		q.Set("algorithm", o.algorithm.String())
+	}
+	if o.digits != defaultDigits {
+		q.Set("digits", strconv.Itoa(o.digits))
+	}
+	return buildURI("totp", issuer, account, EncodeSecret(o.secret), q, opts)
+}
+
+// buildURI assembles an otpauth URI of the given type around a canonical
+// secret and the type-specific parameters in q
+func buildURI(kind, issuer, account, secret string, q url.Values, opts []URIOption) (string, error) {
+	var c uriConfig
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return "", fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	if account == "" {
+		return "", errors.New("account must not be empty")
+	}
+	if strings.ContainsFunc(issuer+account, unicode.IsControl) {
+		return "", errors.New("issuer and account must not contain control characters")
+	}
+
+	label := escapeLabel(account)
+	q.Set("secret", secret)
+	if issuer != "" {
+		label = escapeLabel(issuer) + ":" + label
+		q.Set("issuer", issuer)
+	}
+	if c.image != "" {
+		q.Set("image", c.image)
+	}
+	// Some authenticators show "+" literally, so spaces are sent as %20;
+	// a literal plus is already %2B
+	query := strings.ReplaceAll(q.Encode(), "+", "%20")
+	return "otpauth://" + kind + "/" + label + "?" + query, nil
+}
+
+// escapeLabel escapes an issuer or account for the URI label, including
+// the colon that separates them
+func escapeLabel(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ":", "%3A")
+}
@@ -0,0 +1,319 @@
+package totp
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseURI(t *testing.T) {
+	k, err := ParseURI("otpauth://totp/ACME%20Co:john@example.com?secret=" + rfc6238Secret + "&issuer=ACME%20Co&period=30")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.Issuer != "ACME Co" || k.Account != "john@example.com" {
+		t.Fatalf("got issuer=%q account=%q", k.Issuer, k.Account)
+	}
+	if k.Period != 30*time.Second {
+		t.Fatalf("period=%v, want 30s", k.Period)
+	}
+
+	o, err := k.TOTP()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code, _ := o.TokenAt(time.Unix(59, 0))
+	if code != "287082" {
+		t.Fatalf("got %q, want %q", code, "287082")
+	}
+}
+
+func Test_ParseURI_Algorithm(t *testing.T) {
+	k, err := ParseURI("otpauth://totp/alice?secret=" + rfc6238Secret + "&algorithm=sha256")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.Algorithm != SHA256 {
+		t.Fatalf("algorithm %d, want SHA256", k.Algorithm)
+	}
+	o, err := k.TOTP(WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codes, _ := TokensAllAlgorithms(rfc6238Secret, time.Unix(59, 0), 8)
+	if got, _ := o.TokenAt(time.Unix(59, 0)); got != codes[SHA256] {
+		t.Fatalf("got %q, want %q", got, codes[SHA256])
+	}
+}
+
+func Test_ParseURI_AllowedAlgorithms(t *testing.T) {
+	policy := WithAllowedAlgorithms(SHA256)
+	if _, err := ParseURI("otpauth://totp/alice?secret="+rfc6238Secret+"&algorithm=SHA1", policy); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Fatalf("SHA1 URI: got %v, want ErrAlgorithmNotAllowed", err)
+	}
+	if _, err := ParseURI("otpauth://totp/alice?secret="+rfc6238Secret, policy); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Fatalf("URI without algorithm (SHA1): got %v, want ErrAlgorithmNotAllowed", err)
+	}
+	if _, err := ParseURI("otpauth://totp/alice?secret="+rfc6238Secret+"&algorithm=SHA256", policy); err != nil {
+		t.Fatalf("SHA256 URI: unexpected error: %v", err)
+	}
+}
+
+func Test_Key_Params(t *testing.T) {
+	k, err := ParseURI("otpauth://totp/ACME:alice?secret=gezdgnbvgy3tqojqgezdgnbvgy3tqojq&issuer=ACME&algorithm=sha256&digits=8&period=60")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "ACME",
+		"algorithm": "SHA256",
+		"digits":    "8",
+		"period":    "60",
+	}
+	if got := k.Params(); !maps.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	k, _ = ParseURI("otpauth://hotp/alice?secret=" + rfc6238Secret + "&counter=7")
+	want = map[string]string{"secret": rfc6238Secret, "algorithm": "SHA1", "digits": "6", "counter": "7"}
+	if got := k.Params(); !maps.Equal(got, want) {
+		t.Fatalf("hotp: got %v, want %v", got, want)
+	}
+}
+
+func Test_ParseURI_HOTP(t *testing.T) {
+	k, err := ParseURI("otpauth://hotp/ACME:alice?secret=" + rfc6238Secret + "&issuer=ACME&counter=5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.Type != "hotp" || k.Counter != 5 || k.Period != 0 {
+		t.Fatalf("got %+v, want an hotp key at counter 5", k)
+	}
+	h, err := k.HOTP()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// RFC 4226 Appendix D, count 5
+	if got := h.Token(k.Counter); got != "254676" {
+		t.Fatalf("got %q, want %q", got, "254676")
+	}
+	if _, err := k.TOTP(); err == nil {
+		t.Fatalf("expected error creating a TOTP from an hotp key")
+	}
+
+	for _, uri := range []string{
+		"otpauth://hotp/alice?secret=" + rfc6238Secret,
+		"otpauth://hotp/alice?secret=" + rfc6238Secret + "&counter=-1",
+		"otpauth://hotp/alice?secret=" + rfc6238Secret + "&counter=x",
+	} {
+		if _, err := ParseURI(uri); !errors.Is(err, ErrInvalidURI) {
+			t.Fatalf("%s: got %v, want ErrInvalidURI", uri, err)
+		}
+	}
+}
+
+func Test_ParseURI_SecretVariants(t *testing.T) {
+	// All spell the 10-byte secret "Hello!\xde\xad\xbe\xef"
+	variants := map[string]string{
+		"plain":     "JBSWY3DPEHPK3PXP",
+		"padded":    "JBSWY3DPEHPK3PXP%3D%3D%3D%3D",
+		"lowercase": "jbswy3dpehpk3pxp",
+		"spaced":    "JBSW%20Y3DP%20EHPK%203PXP",
+		"plus":      "jbsw+y3dp+ehpk+3pxp",
+	}
+	want, err := GetTokenAt("JBSWY3DPEHPK3PXP", time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, secret := range variants {
+		k, err := ParseURI("otpauth://totp/alice?secret=" + secret)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if k.Secret != "JBSWY3DPEHPK3PXP" {
+			t.Fatalf("%s: secret=%q, want canonical form", name, k.Secret)
+		}
+		got, err := GetTokenAt(k.Secret, time.Unix(59, 0))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if got != want {
+			t.Fatalf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func Test_GetTokenAt_SecretVariants(t *testing.T) {
+	for _, secret := range []string{"JBSWY3DPEHPK3PXP====", "jbsw y3dp ehpk 3pxp", " JBSWY3DPEHPK3PXP\n"} {
+		got, err := GetTokenAt(secret, time.Unix(59, 0))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", secret, err)
+		}
+		want, _ := GetTokenAt("JBSWY3DPEHPK3PXP", time.Unix(59, 0))
+		if got != want {
+			t.Fatalf("%q: got %q, want %q", secret, got, want)
+		}
+	}
+}
+
+func Test_ParseURI_Invalid(t *testing.T) {
+	for _, uri := range []string{
+		"https://totp/alice?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=not*base32",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=9",
+	} {
+		if _, err := ParseURI(uri); !errors.Is(err, ErrInvalidURI) {
+			t.Fatalf("%s: got %v, want ErrInvalidURI", uri, err)
+		}
+	}
+}
+
+func Test_BuildURI(t *testing.T) {
+	uri, err := BuildURI("ACME Co", "john@example.com", rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	k, err := ParseURI(uri)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", uri, err)
+	}
+	if k.Issuer != "ACME Co" || k.Account != "john@example.com" || k.Secret != rfc6238Secret {
+		t.Fatalf("round-trip mismatch: %+v", k)
+	}
+}
+
+func Test_BuildURI_Labels(t *testing.T) {
+	for _, tc := range []struct {
+		issuer, account string
+	}{
+		{"ACME Co", "john doe@example.com"},
+		{"ACME", "domain:user"},
+		{"", "domain:user"},
+		{"Big:Corp", "alice"},
+		{"Müller GmbH", "jürgen@example.de"},
+		{"例え", "ユーザー"},
+		{"A+B", "x/y?z#w"},
+	} {
+		uri, err := BuildURI(tc.issuer, tc.account, rfc6238Secret)
+		if err != nil {
+			t.Fatalf("%q %q: unexpected error: %v", tc.issuer, tc.account, err)
+		}
+		if strings.ContainsAny(uri, " +") && tc.issuer != "A+B" {
+			t.Fatalf("%q: unescaped space or plus", uri)
+		}
+		k, err := ParseURI(uri)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", uri, err)
+		}
+		if k.Issuer != tc.issuer || k.Account != tc.account {
+			t.Fatalf("%q: got issuer=%q account=%q, want %q %q", uri, k.Issuer, k.Account, tc.issuer, tc.account)
+		}
+	}
+
+	uri, _ := BuildURI("ACME Co", "alice", rfc6238Secret)
+	if want := "otpauth://totp/ACME%20Co:alice?issuer=ACME%20Co&secret=" + rfc6238Secret; uri != want {
+		t.Fatalf("got %q, want %q", uri, want)
+	}
+
+	for _, label := range []string{"alice\n", "ali\x00ce", "a\u0085b"} {
+		if _, err := BuildURI("ACME", label, rfc6238Secret); err == nil {
+			t.Fatalf("%q: expected error for a control character", label)
+		}
+		if _, err := BuildURI(label, "alice", rfc6238Secret); err == nil {
+			t.Fatalf("issuer %q: expected error for a control character", label)
+		}
+	}
+}
+
+func Test_BuildHOTPURI(t *testing.T) {
+	for _, counter := range []uint64{0, 5, 1<<64 - 1} {
+		uri, err := BuildHOTPURI("ACME Co", "alice@example.com", rfc6238Secret, counter)
+		if err != nil {
+			t.Fatalf("counter %d: unexpected error: %v", counter, err)
+		}
+		if !strings.HasPrefix(uri, "otpauth://hotp/") {
+			t.Fatalf("got %q, want an hotp URI", uri)
+		}
+		k, err := ParseURI(uri)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", uri, err)
+		}
+		if k.Type != "hotp" || k.Counter != counter || k.Issuer != "ACME Co" || k.Account != "alice@example.com" || k.Secret != rfc6238Secret {
+			t.Fatalf("%q: round-trip mismatch: %+v", uri, k)
+		}
+	}
+	if _, err := BuildHOTPURI("ACME", "alice", "not*base32", 0); err == nil {
+		t.Fatal("expected error for an invalid secret")
+	}
+}
+
+func Test_TOTP_URI(t *testing.T) {
+	o, err := New(rfc6238Secret, WithPeriod(60*time.Second), WithAlgorithm(SHA512), WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uri, err := o.URI("ACME", "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	k, err := ParseURI(uri)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", uri, err)
+	}
+	back, err := k.TOTP()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !SameParams(o, back) {
+		t.Fatalf("round-trip through %q changed parameters", uri)
+	}
+
+	plain, _ := New(rfc6238Secret)
+	uri, _ = plain.URI("ACME", "alice")
+	want, _ := BuildURI("ACME", "alice", rfc6238Secret)
+	if uri != want {
+		t.Fatalf("default URI %q, want %q", uri, want)
+	}
+
+	stripped, _ := New(rfc6238Secret, WithoutLeadingZeros())
+	if _, err := stripped.URI("ACME", "alice"); !errors.Is(err, ErrNotExportable) {
+		t.Fatalf("got %v, want ErrNotExportable", err)
+	}
+}
+
+func Test_BuildURI_Image(t *testing.T) {
+	plain, err := BuildURI("ACME", "alice", rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(plain, "image=") {
+		t.Fatalf("unexpected image parameter in %q", plain)
+	}
+
+	withImage, err := BuildURI("ACME", "alice", rfc6238Secret, WithImage("https://example.com/logo.png?size=64&x=1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u, err := url.Parse(withImage)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := u.Query().Get("image"); got != "https://example.com/logo.png?size=64&x=1" {
+		t.Fatalf("image=%q", got)
+	}
+	if !strings.Contains(withImage, "image=https%3A%2F%2Fexample.com%2Flogo.png%3Fsize%3D64%26x%3D1") {
+		t.Fatalf("image not URL-encoded in %q", withImage)
+	}
+
+	if _, err := BuildURI("ACME", "alice", rfc6238Secret, WithImage("not a url")); err == nil {
+		t.Fatal("expected error for invalid image URL, got nil")
+	}
+}
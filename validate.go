@@ -0,0 +1,498 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrNegativeSkew is returned when a validation skew is below zero
+var ErrNegativeSkew = errors.New("skew must not be negative")
+
+// ErrSkewTooLarge is returned when a validation skew exceeds MaxAllowedSkew
+var ErrSkewTooLarge = errors.New("skew exceeds the allowed maximum")
+
+// MaxAllowedSkew is the largest skew validation accepts, 10 windows on each
+// side by default, as a guard against an accidentally huge acceptance
+// window. Raise it during initialization if a wider skew is intended; it
+// is not safe to change while validating.
+var MaxAllowedSkew = 10
+
+// checkSkew rejects a skew below zero or above MaxAllowedSkew
+func checkSkew(skew int) error {
+	if skew < 0 {
+		return ErrNegativeSkew
+	}
+	if skew > MaxAllowedSkew {
+		return fmt.Errorf("%w: %d, at most %d", ErrSkewTooLarge, skew, MaxAllowedSkew)
+	}
+	return nil
+}
+
+// ErrDigitMismatch is returned when a token's length differs from the
+// configured code length, which usually means the stored digit count
+// disagrees with the authenticator rather than a wrong code
+var ErrDigitMismatch = errors.New("token length does not match the configured digits")
+
+// ErrNonDigitToken is returned when a token contains anything but digits
+// once whitespace is removed
+var ErrNonDigitToken = errors.New("token must contain only digits")
+
+// Match
+// Describe the window a validated token belongs to
+type Match struct {
+	// Offset of the matched window relative to the validation time, in steps
+	Offset int
+	// Counter is the time-step counter of the matched window
+	Counter uint64
+	// RemainingSeconds until the matched window ends, measured from the
+	// validation time. Zero when the matched window is already over.
+	RemainingSeconds int
+
+	step int64 // window length in seconds, for Age
+}
+
+// Age
+// Return how far the matched window is from the validation time's window,
+// in either direction: one period for Offset -1 or 1, zero for the current
+// window. Matches at large ages point to replay or a badly set clock.
+func (m Match) Age() time.Duration {
+	offset := m.Offset
+	if offset < 0 {
+		offset = -offset
+	}
+	return time.Duration(offset) * time.Duration(m.step) * time.Second
+}
+
+// Key
+// Return a stable identifier for the matched window, "v1:<counter>", for
+// idempotency or replay storage. Tokens accepted in the same window share a
+// key; the "v1:" prefix leaves room to change the format later. The key does
+// not identify the secret, so scope it per user when storing it.
+func (m Match) Key() string {
+	return "v1:" + strconv.FormatUint(m.Counter, 10)
+}
+
+// Verify
+// Check a token with the recommended policy for most applications: the
+// current window and the one before it, covering a code typed just before
+// a boundary and submitted just after, but no future windows.
+// WithStrictTime narrows this to the current window; use Validate or
+// ValidateOffsets for an explicit skew.
+func Verify(secretKey, token string, opts ...VerifyOption) (bool, error) {
+	c := verifyConfig{offsets: verifyOffsets}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return false, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	_, ok, err := ValidateOffsets(secretKey, token, c.offsets)
+	return ok, err
+}
+
+// verifyOffsets is the acceptance set of Verify
+var verifyOffsets = []int{0, -1}
+
+// VerifyOption
+// Configure Verify
+type VerifyOption func(*verifyConfig) error
+
+// verifyConfig holds the settings collected from verify options
+type verifyConfig struct {
+	offsets []int
+}
+
+// WithStrictTime
+// Accept only the current window (skew 0). This minimizes the acceptance
+// window but rejects a code typed just before a boundary, so it is only
+// appropriate for fleets whose clocks are disciplined by NTP and whose
+// submissions arrive without noticeable latency.
+func WithStrictTime() VerifyOption {
+	return func(c *verifyConfig) error {
+		c.offsets = []int{0}
+		return nil
+	}
+}
+
+// VerifyWithGrace
+// Like Verify, but accept the previous window only during the first grace
+// of the current one, when a code typed just before the boundary may still
+// be in flight, rather than for the whole window
+func VerifyWithGrace(secretKey, token string, grace time.Duration) (bool, error) {
+	return VerifyWithGraceAt(secretKey, token, timeNow(), grace)
+}
+
+// VerifyWithGraceAt
+// Like VerifyWithGrace, at time t. The position in the window comes from
+// RemainingSeconds; grace must be between zero and one period.
+func VerifyWithGraceAt(secretKey, token string, t time.Time, grace time.Duration) (bool, error) {
+	if grace < 0 || grace > defaultPeriod*time.Second {
+		return false, fmt.Errorf("grace must be between 0 and %ds, got %v", defaultPeriod, grace)
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return false, err
+	}
+	offsets := []int{0}
+	elapsed := time.Duration(defaultPeriod-RemainingSeconds(t)) * time.Second
+	if elapsed < grace {
+		offsets = verifyOffsets
+	}
+	_, ok, err := o.matchOffsets(token, t.Unix(), offsets)
+	return ok, err
+}
+
+// Validate
+// Check a token against the current time, accepting skew windows on each side
+func Validate(secretKey, token string, skew int) (bool, error) {
+	return ValidateAt(secretKey, token, timeNow(), skew)
+}
+
+// ValidateAt
+// Check a token against time t, accepting skew windows on each side
+func ValidateAt(secretKey, token string, t time.Time, skew int) (bool, error) {
+	_, ok, err := ValidateDetailed(secretKey, token, t, skew)
+	return ok, err
+}
+
+// Validate
+// Check a token against the current time, accepting skew windows on each side
+func (o *TOTP) Validate(token string, skew int) (bool, error) {
+	return o.ValidateAt(token, o.now(), skew)
+}
+
+// ValidateAt
+// Check a token against time t, accepting skew windows on each side
+func (o *TOTP) ValidateAt(token string, t time.Time, skew int) (bool, error) {
+	if err := checkSkew(skew); err != nil {
+		return false, err
+	}
+	_, ok, err := o.match(token, t.Unix(), skew)
+	return ok, err
+}
+
+// ValidateStrictAt
+// Check a token against exactly the window containing t, with no skew, for
+// forensic replay of recorded submissions where drift must not be tolerated
+func ValidateStrictAt(secretKey, token string, t time.Time) (bool, error) {
+	return ValidateAt(secretKey, token, t, 0)
+}
+
+// ValidateAtUnix
+// Check a token against a Unix timestamp in seconds, accepting skew windows
+// on each side
+func ValidateAtUnix(secretKey, token string, unix int64, skew int) (bool, error) {
+	return ValidateAt(secretKey, token, time.Unix(unix, 0), skew)
+}
+
+// ValidateBytes
+// Check a token against time t for a raw key, such as one from a KMS,
+// without a base32 round-trip: SHA-1, the 30s period and the given number
+// of digits (as WithDigits), accepting skew windows on each side
+func ValidateBytes(key []byte, token string, t time.Time, skew, digits int) (bool, error) {
+	if len(key) == 0 {
+		return false, errors.New("key must not be empty")
+	}
+	if err := checkSkew(skew); err != nil {
+		return false, err
+	}
+	c, err := newConfig([]Option{WithDigits(digits)})
+	if err != nil {
+		return false, err
+	}
+	o, err := newTOTP(key, c)
+	if err != nil {
+		return false, err
+	}
+	_, ok, err := o.match(token, t.Unix(), skew)
+	return ok, err
+}
+
+// ValidateOffsets
+// Check a token against the current time, accepting exactly the listed
+// window offsets (e.g. {0, -1}) and returning the one that matched. This
+// expresses both symmetric and asymmetric skew policies.
+func ValidateOffsets(secretKey, token string, offsets []int) (int, bool, error) {
+	o, err := New(secretKey)
+	if err != nil {
+		return 0, false, err
+	}
+	m, ok, err := o.matchOffsets(token, timeNow().Unix(), offsets)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	return m.Offset, true, nil
+}
+
+// VerifyLastMinute
+// Accept a code that was valid at any moment in the last 60 seconds, up to
+// now. This is backward-only skew expressed in wall-clock time: with the
+// 30s period it covers the current window and the two before it.
+func VerifyLastMinute(secretKey, token string) (bool, error) {
+	o, err := New(secretKey)
+	if err != nil {
+		return false, err
+	}
+	ts := timeNow().Unix()
+	offsets, err := o.lookbackOffsets(ts, time.Minute)
+	if err != nil {
+		return false, err
+	}
+	_, ok, err := o.matchOffsets(token, ts, offsets)
+	return ok, err
+}
+
+// lookbackOffsets
+// Return the offsets 0, -1, ... of every window overlapping [ts-d, ts]
+func (o *TOTP) lookbackOffsets(ts int64, d time.Duration) ([]int, error) {
+	now, err := o.counterAt(ts)
+	if err != nil {
+		return nil, err
+	}
+	back := int64(now)
+	if earliest, err := o.counterAt(ts - int64(d/time.Second)); err == nil {
+		back = int64(now - earliest)
+	}
+	offsets := make([]int, 0, back+1)
+	for i := int64(0); i <= back; i++ {
+		offsets = append(offsets, -int(i))
+	}
+	return offsets, nil
+}
+
+// ValidateDetailed
+// Like ValidateAt, but also report which window matched
+func ValidateDetailed(secretKey, token string, t time.Time, skew int) (Match, bool, error) {
+	if err := checkSkew(skew); err != nil {
+		return Match{}, false, err
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return Match{}, false, err
+	}
+
+	return o.match(token, t.Unix(), skew)
+}
+
+// ValidateAtCounter
+// Check a token against a precomputed time-step counter (as returned by
+// CounterAt), accepting skew windows on each side. It is the verify analog
+// of GetTokenAtCounter: a batch verifier computes the counter once and
+// checks many tokens against the same windows without reading the clock.
+func ValidateAtCounter(secretKey, token string, counter uint64, skew int) (bool, error) {
+	if err := checkSkew(skew); err != nil {
+		return false, err
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return false, err
+	}
+	token, err = o.checkToken(token)
+	if err != nil {
+		return false, err
+	}
+	_, ok := o.matchCounter(token, counter, o.windowStart(counter), skewOffsets(skew))
+	return ok, nil
+}
+
+// maxDiagnosticRange bounds the search of ValidateExplained
+const maxDiagnosticRange = 120
+
+// FailureReason
+// Why ValidateExplained rejected a token
+type FailureReason int
+
+const (
+	// FailureNone means the token was accepted
+	FailureNone FailureReason = iota
+	// FailureWrongCode means the token matched no window in the diagnostic
+	// range: a mistyped code or the wrong secret
+	FailureWrongCode
+	// FailureClockDrift means the token matched a window outside the skew
+	// but inside the diagnostic range, so the device clock is probably off
+	FailureClockDrift
+)
+
+// Explanation
+// The outcome of ValidateExplained
+type Explanation struct {
+	// Valid reports whether the token was accepted within the skew
+	Valid bool
+	// Match describes the accepted window when Valid is true
+	Match Match
+	// Reason is FailureNone when Valid, otherwise why the token was rejected
+	Reason FailureReason
+	// DriftOffset is the offset of the window the token matched, in steps,
+	// when Reason is FailureClockDrift (negative: the device is behind)
+	DriftOffset int
+}
+
+// ValidateExplained
+// Like ValidateDetailed, but on failure search up to diagnosticRange windows
+// on each side (at most 120) to tell a drifting clock from a wrong code, for
+// messages such as "your clock may be off". Only a match within skew is
+// accepted; the wider range is for the explanation alone.
+func ValidateExplained(secretKey, token string, t time.Time, skew, diagnosticRange int) (Explanation, error) {
+	if err := checkSkew(skew); err != nil {
+		return Explanation{}, err
+	}
+	if diagnosticRange < skew || diagnosticRange > maxDiagnosticRange {
+		return Explanation{}, fmt.Errorf("diagnostic range must be between the skew (%d) and %d, got %d", skew, maxDiagnosticRange, diagnosticRange)
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	m, ok, err := o.match(token, t.Unix(), skew)
+	if err != nil {
+		return Explanation{}, err
+	}
+	if ok {
+		return Explanation{Valid: true, Match: m}, nil
+	}
+	m, ok, err = o.match(token, t.Unix(), diagnosticRange)
+	if err != nil {
+		return Explanation{}, err
+	}
+	if ok {
+		return Explanation{Reason: FailureClockDrift, DriftOffset: m.Offset}, nil
+	}
+	return Explanation{Reason: FailureWrongCode}, nil
+}
+
+// MinSkewToAccept
+// Diagnostic: return the smallest skew at which token would be accepted
+// now, searching at most maxSearch windows on each side (up to 120), or
+// ok=false if it matches none. It tells a support agent how many steps a
+// user's clock is off; see ValidateExplained for the direction.
+func MinSkewToAccept(secretKey, token string, maxSearch int) (int, bool, error) {
+	if maxSearch < 0 || maxSearch > maxDiagnosticRange {
+		return 0, false, fmt.Errorf("search range must be between 0 and %d, got %d", maxDiagnosticRange, maxSearch)
+	}
+	o, err := New(secretKey)
+	if err != nil {
+		return 0, false, err
+	}
+	// Offsets are tried nearest first, so the first match is the minimum
+	m, ok, err := o.match(token, timeNow().Unix(), maxSearch)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	if m.Offset < 0 {
+		return -m.Offset, true, nil
+	}
+	return m.Offset, true, nil
+}
+
+// skewOffsets
+// Return the window offsets for a symmetric skew: the current window first,
+// then alternating outward (0, -1, 1, -2, 2, ...)
+func skewOffsets(skew int) []int {
+	offsets := make([]int, 0, 2*skew+1)
+	for i := 0; i <= 2*skew; i++ {
+		offset := (i + 1) / 2
+		if i%2 == 1 {
+			offset = -offset
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+// match
+// Search the windows around ts for the token, for a symmetric skew
+func (o *TOTP) match(token string, ts int64, skew int) (Match, bool, error) {
+	return o.matchOffsets(token, ts, skewOffsets(skew))
+}
+
+// matchOffsets
+// Search exactly the listed window offsets around ts for the token, in order
+func (o *TOTP) matchOffsets(token string, ts int64, offsets []int) (Match, bool, error) {
+	token, err := o.checkToken(token)
+	if err != nil {
+		return Match{}, false, err
+	}
+	now, err := o.counterAt(ts)
+	if err != nil {
+		return Match{}, false, err
+	}
+	m, ok := o.matchCounter(token, now, ts, offsets)
+	return m, ok, nil
+}
+
+// checkToken
+// Clean a submitted token and check its length against the configuration
+func (o *TOTP) checkToken(token string) (string, error) {
+	token, err := cleanToken(token)
+	if err != nil {
+		return "", err
+	}
+	if n := o.tokenLength(); n > 0 && len(token) != n {
+		return "", fmt.Errorf("%w: got %d characters, want %d", ErrDigitMismatch, len(token), n)
+	}
+	return token, nil
+}
+
+// matchCounter
+// Search the listed offsets around the counter now for a cleaned token;
+// ts is the validation time, used for the remaining seconds of a match
+func (o *TOTP) matchCounter(token string, now uint64, ts int64, offsets []int) (Match, bool) {
+	if o.rejectTrivial && trivialToken(token) {
+		if !slices.Contains(offsets, 0) {
+			return Match{}, false
+		}
+		offsets = []int{0}
+	}
+	current := int64(now)
+	for _, offset := range offsets {
+		counter := current + int64(offset)
+		if counter < 0 {
+			continue
+		}
+		code := o.code(uint64(counter))
+		if subtle.ConstantTimeCompare([]byte(code), []byte(token)) == 1 {
+			remaining := o.windowStart(uint64(counter)+1) - ts
+			if remaining < 0 {
+				remaining = 0
+			}
+			return Match{
+				Offset:           offset,
+				Counter:          uint64(counter),
+				RemainingSeconds: int(remaining),
+				step:             o.step(),
+			}, true
+		}
+	}
+	return Match{}, false
+}
+
+// trivialToken reports whether a token is one digit repeated
+func trivialToken(token string) bool {
+	return token != "" && strings.Count(token, token[:1]) == len(token)
+}
+
+// cleanToken
+// Remove whitespace, dashes and invisible formatting characters pasted
+// around or inside a token (trailing spaces, grouped display such as
+// "081 804" or "081-804", zero-width spaces), then require what is left to
+// be ASCII digits
+func cleanToken(token string) (string, error) {
+	token = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Pd, r) {
+			return -1
+		}
+		return r
+	}, token)
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return "", ErrNonDigitToken
+		}
+	}
+	return token, nil
+}
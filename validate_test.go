@@ -0,0 +1,504 @@
+package totp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ValidateAt_RFC6238(t *testing.T) {
+	ok, err := ValidateAt(rfc6238Secret, "287082", time.Unix(59, 0), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected RFC code to validate at T=59")
+	}
+
+	ok, err = ValidateAt(rfc6238Secret, "000000", time.Unix(59, 0), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected wrong code to be rejected")
+	}
+}
+
+func Test_ValidateAt_NegativeSkew(t *testing.T) {
+	if _, err := ValidateAt(rfc6238Secret, "287082", time.Unix(59, 0), -1); err == nil {
+		t.Fatal("expected error for negative skew, got nil")
+	}
+}
+
+func Test_ValidateDetailed_RemainingSeconds(t *testing.T) {
+	// Window [1111111080, 1111111110) yields 081804
+	cases := []struct {
+		ts            int64
+		wantOffset    int
+		wantRemaining int
+	}{
+		{ts: 1111111080, wantOffset: 0, wantRemaining: 30}, // first second of the window
+		{ts: 1111111109, wantOffset: 0, wantRemaining: 1},  // last second of the window
+		{ts: 1111111110, wantOffset: -1, wantRemaining: 0}, // next window, code already expired
+		{ts: 1111111079, wantOffset: 1, wantRemaining: 31}, // previous window, code not yet active
+	}
+
+	for _, tc := range cases {
+		m, ok, err := ValidateDetailed(rfc6238Secret, "081804", time.Unix(tc.ts, 0), 1)
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", tc.ts, err)
+		}
+		if !ok {
+			t.Fatalf("ts=%d: expected match", tc.ts)
+		}
+		if m.Offset != tc.wantOffset {
+			t.Fatalf("ts=%d: offset=%d, want %d", tc.ts, m.Offset, tc.wantOffset)
+		}
+		if m.Counter != 1111111080/30 {
+			t.Fatalf("ts=%d: counter=%d, want %d", tc.ts, m.Counter, 1111111080/30)
+		}
+		if m.RemainingSeconds != tc.wantRemaining {
+			t.Fatalf("ts=%d: remaining=%d, want %d", tc.ts, m.RemainingSeconds, tc.wantRemaining)
+		}
+	}
+}
+
+func Test_ValidateAt_LowercaseSecret(t *testing.T) {
+	stored := strings.ToLower(rfc6238Secret)
+
+	code, err := GetTokenAt(rfc6238Secret, time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ok, err := ValidateAt(stored, code, time.Unix(59, 0), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected code to validate against lowercase stored secret")
+	}
+
+	v, err := NewVerifier(" " + stored + " ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, err := v.AcceptAt(code, time.Unix(59, 0)); err != nil || !ok {
+		t.Fatalf("Verifier: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_VerifyFast(t *testing.T) {
+	pinTime(t, time.Unix(1111111111, 0))
+	cases := []struct {
+		token string
+		skew  int
+		want  bool
+	}{
+		{token: "050471", skew: 0, want: true},  // current window
+		{token: "081804", skew: 0, want: false}, // previous window, no skew
+		{token: "081804", skew: 1, want: true},  // previous window within skew
+		{token: "000000", skew: 2, want: false},
+		{token: "05047", skew: 1, want: false},
+		{token: "081 804", skew: 1, want: true}, // grouped input is cleaned
+		{token: "081-804", skew: 1, want: true},
+		{token: "081804 ", skew: 1, want: true},
+	}
+	for _, tc := range cases {
+		got, err := VerifyFast(rfc6238Secret, tc.token, tc.skew)
+		if err != nil {
+			t.Fatalf("token=%q: unexpected error: %v", tc.token, err)
+		}
+		if got != tc.want {
+			t.Fatalf("token=%q skew=%d: got %v, want %v", tc.token, tc.skew, got, tc.want)
+		}
+		slow, _ := ValidateAt(rfc6238Secret, tc.token, timeNow(), tc.skew)
+		if got != slow {
+			t.Fatalf("token=%q skew=%d: VerifyFast=%v, ValidateAt=%v", tc.token, tc.skew, got, slow)
+		}
+	}
+	if _, err := VerifyFast(rfc6238Secret, "08180x", 1); !errors.Is(err, ErrNonDigitToken) {
+		t.Fatalf("got %v, want ErrNonDigitToken", err)
+	}
+}
+
+func Test_ValidateAt_DigitMismatch(t *testing.T) {
+	o, err := New(rfc6238Secret, WithDigits(8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	at := time.Unix(59, 0)
+
+	// A 6-digit token against an 8-digit config is a misconfiguration
+	if _, err := o.ValidateAt("287082", at, 1); !errors.Is(err, ErrDigitMismatch) {
+		t.Fatalf("got %v, want ErrDigitMismatch", err)
+	}
+
+	// A wrong but correctly sized code is a plain non-match
+	ok, err := o.ValidateAt("00000000", at, 1)
+	if err != nil || ok {
+		t.Fatalf("got (%v, %v), want (false, nil)", ok, err)
+	}
+	if ok, err := o.ValidateAt("94287082", at, 0); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+
+	if _, err := ValidateAt(rfc6238Secret, "94287082", at, 0); !errors.Is(err, ErrDigitMismatch) {
+		t.Fatalf("package ValidateAt: got %v, want ErrDigitMismatch", err)
+	}
+}
+
+func Test_ValidateAtUnix(t *testing.T) {
+	ok, err := ValidateAtUnix(rfc6238Secret, "287082", 59, 0)
+	if err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+	ok, err = ValidateAtUnix(rfc6238Secret, "287082", 60, 0)
+	if err != nil || ok {
+		t.Fatalf("next window: got (%v, %v), want (false, nil)", ok, err)
+	}
+}
+
+func Test_ValidateOffsets(t *testing.T) {
+	pinTime(t, time.Unix(1111111111, 0)) // counter 37037037
+	allowed := []int{0, -1}
+
+	cases := []struct {
+		ts         int64 // time the token was generated
+		wantOK     bool
+		wantOffset int
+	}{
+		{ts: 1111111111, wantOK: true, wantOffset: 0},
+		{ts: 1111111081, wantOK: true, wantOffset: -1},
+		{ts: 1111111051, wantOK: false}, // offset -2
+		{ts: 1111111141, wantOK: false}, // offset +1
+	}
+	for _, tc := range cases {
+		token, _ := GetTokenAt(rfc6238Secret, time.Unix(tc.ts, 0))
+		offset, ok, err := ValidateOffsets(rfc6238Secret, token, allowed)
+		if err != nil {
+			t.Fatalf("ts=%d: unexpected error: %v", tc.ts, err)
+		}
+		if ok != tc.wantOK || offset != tc.wantOffset {
+			t.Fatalf("ts=%d: got (%d, %v), want (%d, %v)", tc.ts, offset, ok, tc.wantOffset, tc.wantOK)
+		}
+	}
+}
+
+func Test_Match_Key(t *testing.T) {
+	// 1111111109 and 1111111100 share the window with counter 37037036
+	a, ok, err := ValidateDetailed(rfc6238Secret, "081804", time.Unix(1111111109, 0), 1)
+	if err != nil || !ok {
+		t.Fatalf("got ok=%v err=%v, want a match", ok, err)
+	}
+	b, ok, err := ValidateDetailed(rfc6238Secret, "081804", time.Unix(1111111100, 0), 1)
+	if err != nil || !ok {
+		t.Fatalf("got ok=%v err=%v, want a match", ok, err)
+	}
+	if a.Key() != "v1:37037036" {
+		t.Fatalf("got %q, want %q", a.Key(), "v1:37037036")
+	}
+	if a.Key() != b.Key() {
+		t.Fatalf("same window gave different keys: %q vs %q", a.Key(), b.Key())
+	}
+
+	// The code from the next window, accepted through skew, keys differently
+	next, _ := GetTokenAtCounter(rfc6238Secret, 37037037)
+	c, ok, _ := ValidateDetailed(rfc6238Secret, next, time.Unix(1111111109, 0), 1)
+	if !ok || c.Key() == a.Key() {
+		t.Fatalf("next window: ok=%v key=%q, want a distinct key", ok, c.Key())
+	}
+}
+
+func Test_ValidateAt_Whitespace(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	for _, token := range []string{"081804 ", " 081804", "081 804 ", "081 804", "\u200b081804\n"} {
+		if ok, err := ValidateAt(rfc6238Secret, token, at, 0); err != nil || !ok {
+			t.Fatalf("%q: got (%v, %v), want (true, nil)", token, ok, err)
+		}
+	}
+	for _, token := range []string{"081_804", "08180x", "\uff10\uff18\uff11\uff18\uff10\uff14"} {
+		if _, err := ValidateAt(rfc6238Secret, token, at, 0); !errors.Is(err, ErrNonDigitToken) {
+			t.Fatalf("%q: got %v, want ErrNonDigitToken", token, err)
+		}
+	}
+}
+
+func Test_ValidateExplained(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+
+	e, err := ValidateExplained(rfc6238Secret, "081804", at, 1, 10)
+	if err != nil || !e.Valid || e.Reason != FailureNone {
+		t.Fatalf("current code: got %+v, %v", e, err)
+	}
+
+	// The code from two windows back is outside skew 1 but within the range
+	old, _ := GetTokenAt(rfc6238Secret, at.Add(-60*time.Second))
+	e, err = ValidateExplained(rfc6238Secret, old, at, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Valid || e.Reason != FailureClockDrift || e.DriftOffset != -2 {
+		t.Fatalf("drifted code: got %+v, want clock drift at offset -2", e)
+	}
+
+	e, err = ValidateExplained(rfc6238Secret, "000000", at, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Valid || e.Reason != FailureWrongCode {
+		t.Fatalf("wrong code: got %+v, want FailureWrongCode", e)
+	}
+
+	if _, err := ValidateExplained(rfc6238Secret, "081804", at, 2, 1); err == nil {
+		t.Fatalf("expected error for a range narrower than the skew")
+	}
+}
+
+func Test_VerifyLastMinute(t *testing.T) {
+	// Mid-window at 1111111100: the last minute reaches back into the window
+	// starting at 1111111020, two windows before the current one
+	pinTime(t, time.Unix(1111111100, 0))
+	for _, at := range []int64{1111111100, 1111111060, 1111111040} {
+		code, _ := GetTokenAt(rfc6238Secret, time.Unix(at, 0))
+		if ok, err := VerifyLastMinute(rfc6238Secret, code); err != nil || !ok {
+			t.Fatalf("code from %d: got (%v, %v), want (true, nil)", at, ok, err)
+		}
+	}
+	for _, at := range []int64{1111111019, 1111111110} {
+		code, _ := GetTokenAt(rfc6238Secret, time.Unix(at, 0))
+		if ok, err := VerifyLastMinute(rfc6238Secret, code); err != nil || ok {
+			t.Fatalf("code from %d: got (%v, %v), want (false, nil)", at, ok, err)
+		}
+	}
+}
+
+func Test_ValidateStrictAt(t *testing.T) {
+	// 081804 is the code of the window [1111111080, 1111111110)
+	for _, ts := range []int64{1111111080, 1111111109} {
+		if ok, err := ValidateStrictAt(rfc6238Secret, "081804", time.Unix(ts, 0)); err != nil || !ok {
+			t.Fatalf("ts=%d: got (%v, %v), want (true, nil)", ts, ok, err)
+		}
+	}
+	for _, ts := range []int64{1111111079, 1111111110} {
+		if ok, err := ValidateStrictAt(rfc6238Secret, "081804", time.Unix(ts, 0)); err != nil || ok {
+			t.Fatalf("ts=%d: got (%v, %v), want (false, nil)", ts, ok, err)
+		}
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	// Codes from the windows around the one of 081804
+	pinTime(t, time.Unix(1111111109, 0))
+	for _, tc := range []struct {
+		offset int
+		want   bool
+	}{
+		{0, true}, {-1, true}, {1, false}, {-2, false},
+	} {
+		code, _ := GetTokenAt(rfc6238Secret, time.Unix(1111111109+int64(tc.offset)*30, 0))
+		ok, err := Verify(rfc6238Secret, code)
+		if err != nil {
+			t.Fatalf("offset %d: unexpected error: %v", tc.offset, err)
+		}
+		if ok != tc.want {
+			t.Fatalf("offset %d: got %v, want %v", tc.offset, ok, tc.want)
+		}
+	}
+}
+
+func Test_Verify_WithStrictTime(t *testing.T) {
+	pinTime(t, time.Unix(1111111109, 0))
+	prev, _ := GetTokenAtCounter(rfc6238Secret, 37037035)
+	if ok, err := Verify(rfc6238Secret, prev, WithStrictTime()); err != nil || ok {
+		t.Fatalf("previous window: got (%v, %v), want (false, nil)", ok, err)
+	}
+	if ok, err := Verify(rfc6238Secret, prev); err != nil || !ok {
+		t.Fatalf("previous window by default: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := Verify(rfc6238Secret, "081804", WithStrictTime()); err != nil || !ok {
+		t.Fatalf("current window: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_ValidateAtCounter(t *testing.T) {
+	// RFC 6238: T=1111111109 is counter 37037036, code 081804
+	if ok, err := ValidateAtCounter(rfc6238Secret, "081804", 37037036, 0); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := ValidateAtCounter(rfc6238Secret, "081804", 37037037, 0); err != nil || ok {
+		t.Fatalf("next counter without skew: got (%v, %v), want (false, nil)", ok, err)
+	}
+	if ok, err := ValidateAtCounter(rfc6238Secret, "081 804", 37037037, 1); err != nil || !ok {
+		t.Fatalf("next counter with skew 1: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if _, err := ValidateAtCounter(rfc6238Secret, "081804", 37037036, -1); !errors.Is(err, ErrNegativeSkew) {
+		t.Fatalf("got %v, want ErrNegativeSkew", err)
+	}
+	if _, err := ValidateAtCounter(rfc6238Secret, "0818", 37037036, 0); !errors.Is(err, ErrDigitMismatch) {
+		t.Fatalf("got %v, want ErrDigitMismatch", err)
+	}
+	// Counter 0 has no earlier window to wrap around to
+	code, _ := GetTokenAtCounter(rfc6238Secret, 0)
+	if ok, err := ValidateAtCounter(rfc6238Secret, code, 0, 1); err != nil || !ok {
+		t.Fatalf("counter 0: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_Match_Age(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	prev, _ := GetTokenAtCounter(rfc6238Secret, 37037035)
+	next, _ := GetTokenAtCounter(rfc6238Secret, 37037037)
+	for token, want := range map[string]time.Duration{"081804": 0, prev: 30 * time.Second, next: 30 * time.Second} {
+		m, ok, err := ValidateDetailed(rfc6238Secret, token, at, 1)
+		if err != nil || !ok {
+			t.Fatalf("%q: got (%v, %v), want a match", token, ok, err)
+		}
+		if got := m.Age(); got != want {
+			t.Fatalf("offset %d: got %v, want %v", m.Offset, got, want)
+		}
+	}
+
+	o, _ := New(rfc6238Secret, WithPeriod(60*time.Second))
+	code, _ := o.TokenAt(at.Add(-2 * time.Minute))
+	m, ok, _ := o.match(code, at.Unix(), 2)
+	if !ok || m.Offset != -2 || m.Age() != 2*time.Minute {
+		t.Fatalf("60s period: got offset %d, age %v, want -2 and 2m", m.Offset, m.Age())
+	}
+}
+
+func Test_WithRejectTrivialTokens(t *testing.T) {
+	o, err := New(rfc6238Secret, WithRejectTrivialTokens())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Counter 37131726 (T=1113951780) genuinely yields 111111
+	at := time.Unix(37131726*30, 0)
+	if ok, err := o.ValidateAt("111111", at, 1); err != nil || !ok {
+		t.Fatalf("real trivial code: got (%v, %v), want (true, nil)", ok, err)
+	}
+	// Once its window has passed it is no longer accepted through skew,
+	// while the default configuration still accepts it
+	if ok, _ := o.ValidateAt("111111", at.Add(30*time.Second), 1); ok {
+		t.Fatal("trivial code accepted through skew")
+	}
+	plain, _ := New(rfc6238Secret)
+	if ok, _ := plain.ValidateAt("111111", at.Add(30*time.Second), 1); !ok {
+		t.Fatal("default configuration rejected a code within skew")
+	}
+	for _, token := range []string{"000000", "999999"} {
+		if ok, err := o.ValidateAt(token, at, 1); err != nil || ok {
+			t.Fatalf("%q: got (%v, %v), want (false, nil)", token, ok, err)
+		}
+	}
+	// Nontrivial codes keep their skew
+	prev, _ := GetTokenAtCounter(rfc6238Secret, 37037035)
+	if ok, _ := o.ValidateAt(prev, time.Unix(1111111109, 0), 1); !ok {
+		t.Fatal("nontrivial code rejected within skew")
+	}
+}
+
+func Test_ValidateBytes(t *testing.T) {
+	key := []byte("12345678901234567890") // the RFC 6238 seed
+	at := time.Unix(1111111109, 0)
+	for digits, token := range map[int]string{6: "081804", 8: "07081804"} {
+		if ok, err := ValidateBytes(key, token, at, 0, digits); err != nil || !ok {
+			t.Fatalf("%d digits: got (%v, %v), want (true, nil)", digits, ok, err)
+		}
+	}
+	if ok, err := ValidateBytes(key, "050471", at, 1, 6); err != nil || !ok {
+		t.Fatalf("next window with skew 1: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := ValidateBytes(key, "081805", at, 1, 6); err != nil || ok {
+		t.Fatalf("wrong code: got (%v, %v), want (false, nil)", ok, err)
+	}
+	if _, err := ValidateBytes(key, "081804", at, 0, 8); !errors.Is(err, ErrDigitMismatch) {
+		t.Fatalf("got %v, want ErrDigitMismatch", err)
+	}
+	if _, err := ValidateBytes(nil, "081804", at, 0, 6); err == nil {
+		t.Fatal("expected error for an empty key")
+	}
+	if _, err := ValidateBytes(key, "081804", at, 0, 0); err == nil {
+		t.Fatal("expected error for zero digits")
+	}
+}
+
+func Test_MaxAllowedSkew(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	if ok, err := ValidateAt(rfc6238Secret, "081804", at, 10); err != nil || !ok {
+		t.Fatalf("skew 10: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if _, err := ValidateAt(rfc6238Secret, "081804", at, 11); !errors.Is(err, ErrSkewTooLarge) {
+		t.Fatalf("skew 11: got %v, want ErrSkewTooLarge", err)
+	}
+	if _, err := ValidateAtCounter(rfc6238Secret, "081804", 37037036, 1000); !errors.Is(err, ErrSkewTooLarge) {
+		t.Fatalf("ValidateAtCounter: got %v, want ErrSkewTooLarge", err)
+	}
+	if _, err := NewVerifier(rfc6238Secret, WithSkew(11)); !errors.Is(err, ErrSkewTooLarge) {
+		t.Fatalf("NewVerifier: got %v, want ErrSkewTooLarge", err)
+	}
+
+	old := MaxAllowedSkew
+	t.Cleanup(func() { MaxAllowedSkew = old })
+	MaxAllowedSkew = 20
+	if ok, err := ValidateAt(rfc6238Secret, "081804", at, 20); err != nil || !ok {
+		t.Fatalf("raised cap: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_VerifyWithGraceAt(t *testing.T) {
+	// 081804 is the code of the window [1111111080, 1111111110)
+	for _, tc := range []struct {
+		ts   int64
+		want bool
+	}{
+		{1111111109, true},  // its own window
+		{1111111110, true},  // 0s into the next window
+		{1111111114, true},  // 4s in, inside the 5s grace
+		{1111111115, false}, // 5s in, grace is over
+		{1111111139, false}, // late in the next window
+		{1111111140, false}, // two windows later
+	} {
+		got, err := VerifyWithGraceAt(rfc6238Secret, "081804", time.Unix(tc.ts, 0), 5*time.Second)
+		if err != nil || got != tc.want {
+			t.Fatalf("T=%d: got (%v, %v), want %v", tc.ts, got, err, tc.want)
+		}
+	}
+	// A full-period grace is Verify
+	if ok, _ := VerifyWithGraceAt(rfc6238Secret, "081804", time.Unix(1111111139, 0), 30*time.Second); !ok {
+		t.Fatal("full grace rejected the previous window")
+	}
+	if _, err := VerifyWithGraceAt(rfc6238Secret, "081804", time.Unix(1111111109, 0), 31*time.Second); err == nil {
+		t.Fatal("expected error for a grace beyond one period")
+	}
+
+	pinTime(t, time.Unix(1111111112, 0))
+	if ok, err := VerifyWithGrace(rfc6238Secret, "081804", 5*time.Second); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func Test_MinSkewToAccept(t *testing.T) {
+	pinTime(t, time.Unix(1111111109, 0))
+	for _, tc := range []struct {
+		counter uint64
+		want    int
+	}{
+		{37037036, 0}, {37037035, 1}, {37037037, 1}, {37037029, 7}, {37037086, 50},
+	} {
+		code, _ := GetTokenAtCounter(rfc6238Secret, tc.counter)
+		got, ok, err := MinSkewToAccept(rfc6238Secret, code, 60)
+		if err != nil || !ok || got != tc.want {
+			t.Fatalf("counter %d: got (%d, %v, %v), want %d", tc.counter, got, ok, err, tc.want)
+		}
+	}
+
+	// Beyond the search range
+	code, _ := GetTokenAtCounter(rfc6238Secret, 37037036+20)
+	if _, ok, err := MinSkewToAccept(rfc6238Secret, code, 10); err != nil || ok {
+		t.Fatalf("out of range: got (%v, %v), want (false, nil)", ok, err)
+	}
+	if _, _, err := MinSkewToAccept(rfc6238Secret, "081804", maxDiagnosticRange+1); err == nil {
+		t.Fatal("expected error for a search beyond the cap")
+	}
+}
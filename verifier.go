@@ -0,0 +1,209 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEmptyToken is returned when Accept is called without a token
+var ErrEmptyToken = errors.New("token must not be empty")
+
+// Verifier
+// Validate tokens against a primary secret and, during a grace period after
+// rotation, against retired secrets. Each accepted code is recorded in a
+// ReplayStore, an in-memory one unless WithReplayStore is given, so a second
+// use fails with ErrTokenReused.
+type Verifier struct {
+	primary  *TOTP
+	retired  []retiredSecret
+	skew     int
+	metrics  Metrics
+	replay   ReplayStore
+	window   time.Duration // see WithReplayWindow
+	totpOpts []Option
+}
+
+// retiredSecret is a secret accepted until expiresAt. It is decoded by
+// NewVerifier once all options are known.
+type retiredSecret struct {
+	secretKey string
+	totp      *TOTP
+	expiresAt time.Time
+}
+
+// VerifierOption
+// Configure a Verifier created with NewVerifier
+type VerifierOption func(*Verifier) error
+
+// WithSkew
+// Accept tokens from skew windows on each side of the current one (default 0)
+func WithSkew(skew int) VerifierOption {
+	return func(v *Verifier) error {
+		if err := checkSkew(skew); err != nil {
+			return err
+		}
+		v.skew = skew
+		return nil
+	}
+}
+
+// WithMetrics
+// Report validation counters to m
+func WithMetrics(m Metrics) VerifierOption {
+	return func(v *Verifier) error {
+		if m == nil {
+			return errors.New("metrics must not be nil")
+		}
+		v.metrics = m
+		return nil
+	}
+}
+
+// WithReplayStore
+// Record accepted codes in store instead of the default in-memory store,
+// e.g. to share replay protection across servers
+func WithReplayStore(store ReplayStore) VerifierOption {
+	return func(v *Verifier) error {
+		if store == nil {
+			return errors.New("replay store must not be nil")
+		}
+		v.replay = store
+		return nil
+	}
+}
+
+// WithReplayWindow
+// Also reject a code whose digits were accepted within the last d, even if
+// they now match a different window. Replay protection is otherwise per
+// window, so with a wide skew the same digits recurring in a neighboring
+// window would be accepted again. The extra store entry lives for d.
+func WithReplayWindow(d time.Duration) VerifierOption {
+	return func(v *Verifier) error {
+		if d <= 0 {
+			return fmt.Errorf("replay window must be positive, got %v", d)
+		}
+		v.window = d
+		return nil
+	}
+}
+
+// WithRetiredSecret
+// Keep accepting tokens from a rotated-out secret until expiresAt
+func WithRetiredSecret(secretKey string, expiresAt time.Time) VerifierOption {
+	return func(v *Verifier) error {
+		v.retired = append(v.retired, retiredSecret{secretKey: secretKey, expiresAt: expiresAt})
+		return nil
+	}
+}
+
+// WithTOTPOptions
+// Apply generation options (e.g. WithPeriod) to the primary and every
+// retired secret
+func WithTOTPOptions(opts ...Option) VerifierOption {
+	return func(v *Verifier) error {
+		v.totpOpts = append(v.totpOpts, opts...)
+		return nil
+	}
+}
+
+// NewVerifier
+// Create a Verifier for the primary secret
+func NewVerifier(primary string, opts ...VerifierOption) (*Verifier, error) {
+	v := &Verifier{metrics: noopMetrics{}}
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+
+	var err error
+	if v.replay == nil {
+		if v.replay, err = NewMemoryReplayStore(defaultReplayEntries); err != nil {
+			return nil, err
+		}
+	}
+	v.primary, err = New(primary, v.totpOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range v.retired {
+		v.retired[i].totp, err = New(v.retired[i].secretKey, v.totpOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("retired secret: %w", err)
+		}
+		v.retired[i].secretKey = ""
+	}
+	return v, nil
+}
+
+// AcceptanceWindow
+// Return the total time span during which a code is accepted:
+// (2*skew+1)*period, e.g. 90s for a 30s period and a skew of 1
+func (v *Verifier) AcceptanceWindow() time.Duration {
+	return time.Duration(2*v.skew+1) * time.Duration(v.primary.step()) * time.Second
+}
+
+// Accept
+// Check the token against the current time
+func (v *Verifier) Accept(token string) (bool, error) {
+	return v.AcceptAt(token, v.primary.now())
+}
+
+// AcceptAt
+// Check the token at time t: the primary secret first, then every retired
+// secret whose grace period has not ended at t
+func (v *Verifier) AcceptAt(token string, t time.Time) (bool, error) {
+	if token == "" {
+		return false, ErrEmptyToken
+	}
+	v.metrics.IncValidation()
+	m, ok, err := v.primary.match(token, t.Unix(), v.skew)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return v.claim(v.primary, m, token, t)
+	}
+	for _, r := range v.retired {
+		if !t.Before(r.expiresAt) {
+			continue
+		}
+		// checkToken already passed with identical options on the primary,
+		// so match cannot return an error here
+		if m, ok, _ := r.totp.match(token, t.Unix(), v.skew); ok {
+			return v.claim(r.totp, m, token, t)
+		}
+	}
+	return false, nil
+}
+
+// claim records a match of o in the replay store, accepting it only on
+// first use. The entry lives until the code leaves the skew; under
+// WithReplayWindow the digits are also recorded until t plus the window.
+func (v *Verifier) claim(o *TOTP, m Match, token string, t time.Time) (bool, error) {
+	expiresAt := time.Unix(o.windowStart(m.Counter+1+uint64(v.skew)), 0).UTC()
+	first, err := v.replay.Claim(fingerprint(o.secret)+":"+m.Key(), t, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	if first && v.window > 0 {
+		// token matched, so it is known to clean without error
+		digits, _ := cleanToken(token)
+		first, err = v.replay.Claim(fingerprint(o.secret)+":code:"+digits, t, t.Add(v.window).UTC())
+		if err != nil {
+			return false, err
+		}
+	}
+	if !first {
+		return false, ErrTokenReused
+	}
+	v.observe(m)
+	return true, nil
+}
+
+// observe reports an accepted match to the metrics
+func (v *Verifier) observe(m Match) {
+	v.metrics.IncMatch()
+	v.metrics.ObserveOffset(m.Offset)
+}
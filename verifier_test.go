@@ -0,0 +1,132 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// otherSecret is an arbitrary base32 secret distinct from rfc6238Secret
+const otherSecret = "JBSWY3DPEHPK3PXP"
+
+func Test_Verifier_Primary(t *testing.T) {
+	v, err := NewVerifier(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ok, err := v.AcceptAt("287082", time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected primary code to be accepted")
+	}
+}
+
+func Test_Verifier_RetiredGracePeriod(t *testing.T) {
+	// The old secret was rfc6238Secret; it is accepted until T=80
+	v, err := NewVerifier(otherSecret,
+		WithRetiredSecret(rfc6238Secret, time.Unix(80, 0)),
+		WithSkew(1),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := v.AcceptAt("287082", time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected old code to be accepted during grace period")
+	}
+
+	// T=85 is after the grace period; the skew still covers the T=59 window
+	ok, err = v.AcceptAt("287082", time.Unix(85, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected old code to be rejected after grace period")
+	}
+}
+
+func Test_Verifier_InvalidOptions(t *testing.T) {
+	if _, err := NewVerifier(rfc6238Secret, WithSkew(-1)); err == nil {
+		t.Fatal("expected error for negative skew, got nil")
+	}
+	if _, err := NewVerifier(rfc6238Secret, WithRetiredSecret("not*base32==", time.Now())); err == nil {
+		t.Fatal("expected error for invalid retired secret, got nil")
+	}
+	if _, err := NewVerifier(rfc6238Secret, WithReplayStore(nil)); err == nil {
+		t.Fatal("expected error for nil replay store, got nil")
+	}
+}
+
+type countingMetrics struct {
+	validations int
+	matches     int
+	offsets     []int
+}
+
+func (m *countingMetrics) IncValidation()           { m.validations++ }
+func (m *countingMetrics) IncMatch()                { m.matches++ }
+func (m *countingMetrics) ObserveOffset(offset int) { m.offsets = append(m.offsets, offset) }
+
+func Test_Verifier_Metrics(t *testing.T) {
+	m := &countingMetrics{}
+	v, err := NewVerifier(rfc6238Secret, WithSkew(1), WithMetrics(m))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// T=60 is one window after the T=59 code
+	if ok, err := v.AcceptAt("287082", time.Unix(60, 0)); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := v.AcceptAt("000000", time.Unix(60, 0)); err != nil || ok {
+		t.Fatalf("got (%v, %v), want (false, nil)", ok, err)
+	}
+
+	if m.validations != 2 {
+		t.Fatalf("validations=%d, want 2", m.validations)
+	}
+	if m.matches != 1 {
+		t.Fatalf("matches=%d, want 1", m.matches)
+	}
+	if len(m.offsets) != 1 || m.offsets[0] != -1 {
+		t.Fatalf("offsets=%v, want [-1]", m.offsets)
+	}
+}
+
+func Test_Verifier_AcceptanceWindow(t *testing.T) {
+	cases := []struct {
+		period time.Duration
+		skew   int
+		want   time.Duration
+	}{
+		{period: 30 * time.Second, skew: 0, want: 30 * time.Second},
+		{period: 30 * time.Second, skew: 1, want: 90 * time.Second},
+		{period: 30 * time.Second, skew: 2, want: 150 * time.Second},
+		{period: 60 * time.Second, skew: 1, want: 180 * time.Second},
+	}
+	for _, tc := range cases {
+		v, err := NewVerifier(rfc6238Secret, WithSkew(tc.skew), WithTOTPOptions(WithPeriod(tc.period)))
+		if err != nil {
+			t.Fatalf("period=%v skew=%d: unexpected error: %v", tc.period, tc.skew, err)
+		}
+		if got := v.AcceptanceWindow(); got != tc.want {
+			t.Fatalf("period=%v skew=%d: got %v, want %v", tc.period, tc.skew, got, tc.want)
+		}
+	}
+}
+
+func Test_Verifier_CustomPeriod(t *testing.T) {
+	v, err := NewVerifier(rfc6238Secret, WithTOTPOptions(WithPeriod(60*time.Second)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// With a 60s step T=118 uses counter 1, whose code is 287082
+	if ok, err := v.AcceptAt("287082", time.Unix(118, 0)); err != nil || !ok {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+}